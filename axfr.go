@@ -0,0 +1,363 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// Timeout for connecting and for each message of a transfer
+const axfrTimeout = 30 * time.Second
+
+// TransferZone requests a full zone transfer (AXFR) of zone from server and
+// converts the records exactly like ParseZoneFile does for a file. The
+// server is host[:port], port 53 by default. key is optional.
+func TransferZone(server, zone string, key *NamedKey, opts conversionOptions) (*ZoneConfig, error) {
+	zone = strings.TrimSuffix(resolveOrigin(zone), ".")
+	if zone == "" {
+		return nil, fmt.Errorf("a zone name is required for a zone transfer")
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+
+	lines, err := axfr(server, zone, key)
+	if err != nil {
+		return nil, fmt.Errorf("zone transfer of %s from %s failed: %v", zone, server, err)
+	}
+
+	// The transferred records go through the same reader as a zone file
+	source := "axfr://" + server + "/" + zone
+	text := "$ORIGIN " + zone + ".\n" + strings.Join(lines, "\n") + "\n"
+	reader := newZoneReader(zone, "", defaultTTLValue, opts)
+	if err := reader.read(strings.NewReader(text), source); err != nil {
+		return nil, err
+	}
+
+	return convertZoneRecords(reader, zone, opts)
+}
+
+// axfr runs the transfer and returns the records as master file lines, the
+// closing copy of the SOA left out.
+func axfr(server, zone string, key *NamedKey) ([]string, error) {
+	var signer *tsigSigner
+	if key != nil {
+		var err error
+		if signer, err = newTSIGSigner(*key); err != nil {
+			return nil, err
+		}
+	}
+
+	var idBytes [2]byte
+	if _, err := rand.Read(idBytes[:]); err != nil {
+		return nil, err
+	}
+	id := binary.BigEndian.Uint16(idBytes[:])
+
+	query, err := newDNSQuery(id, zone, dnsTypeAXFR, dnsClassIN)
+	if err != nil {
+		return nil, err
+	}
+	if signer != nil {
+		if query, err = signer.sign(query); err != nil {
+			return nil, err
+		}
+	}
+
+	conn, err := net.DialTimeout("tcp", server, axfrTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(axfrTimeout))
+	if _, err := conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(query))), query...)); err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	soaCount := 0
+	for soaCount < 2 {
+		conn.SetDeadline(time.Now().Add(axfrTimeout))
+		msg, err := readTCPMessage(conn)
+		if err != nil {
+			return nil, err
+		}
+
+		m, err := parseDNSMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		if m.ID != id {
+			return nil, fmt.Errorf("response ID %d does not match query ID %d", m.ID, id)
+		}
+		if rcode := m.rcode(); rcode != 0 {
+			name := dnsRcodeNames[rcode]
+			if name == "" {
+				name = fmt.Sprintf("RCODE %d", rcode)
+			}
+			return nil, fmt.Errorf("server answered %s", name)
+		}
+		if signer != nil {
+			if err := signer.verify(msg, m); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, rr := range m.Answers {
+			if len(lines) == 0 && rr.Type != dnsTypeSOA {
+				return nil, fmt.Errorf("transfer does not start with the SOA record")
+			}
+			if rr.Type == dnsTypeSOA {
+				if soaCount++; soaCount == 2 {
+					break
+				}
+			}
+			line, err := rr.presentation(msg)
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, line)
+		}
+		if len(m.Answers) == 0 {
+			return nil, fmt.Errorf("server sent an empty answer")
+		}
+	}
+
+	if signer != nil && !signer.lastSigned {
+		return nil, fmt.Errorf("the last message of the transfer is not signed")
+	}
+
+	return lines, nil
+}
+
+// readTCPMessage reads one length-prefixed DNS message.
+func readTCPMessage(r io.Reader) ([]byte, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// TSIG algorithm names on the wire (RFC 8945) and their hashes
+var tsigHashes = map[string]struct {
+	name string
+	hash func() hash.Hash
+}{
+	"hmac-md5":                 {"hmac-md5.sig-alg.reg.int", md5.New},
+	"hmac-md5.sig-alg.reg.int": {"hmac-md5.sig-alg.reg.int", md5.New},
+	"hmac-sha1":                {"hmac-sha1", sha1.New},
+	"hmac-sha224":              {"hmac-sha224", sha256.New224},
+	"hmac-sha256":              {"hmac-sha256", sha256.New},
+	"hmac-sha384":              {"hmac-sha384", sha512.New384},
+	"hmac-sha512":              {"hmac-sha512", sha512.New},
+}
+
+// Seconds of clock skew allowed between us and the server
+const tsigFudge = 300
+
+// tsigSigner signs a query and verifies the signed messages of the answer
+// (RFC 8945 section 5.3.1). A transfer may leave messages unsigned between
+// signed ones, they are covered by the next signature.
+type tsigSigner struct {
+	keyName    string
+	algorithm  string
+	hash       func() hash.Hash
+	secret     []byte
+	queryID    uint16
+	prevMAC    []byte
+	unsigned   []byte // messages received since the last signed one
+	signed     int
+	lastSigned bool
+}
+
+func newTSIGSigner(key NamedKey) (*tsigSigner, error) {
+	algorithm, exists := tsigHashes[strings.ToLower(strings.TrimSuffix(key.Algorithm, "."))]
+	if !exists {
+		return nil, fmt.Errorf("unsupported TSIG algorithm %q", key.Algorithm)
+	}
+	secret, err := base64.StdEncoding.DecodeString(key.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("TSIG key %q has a secret that is not valid base64", key.Name)
+	}
+
+	return &tsigSigner{
+		keyName:   strings.ToLower(strings.TrimSuffix(key.Name, ".")),
+		algorithm: algorithm.name,
+		hash:      algorithm.hash,
+		secret:    secret,
+	}, nil
+}
+
+// variables returns the TSIG variables covered by the MAC, or only the
+// timers for the later messages of a transfer.
+func (s *tsigSigner) variables(timeSigned uint64, fudge, tsigError uint16, other []byte, timersOnly bool) []byte {
+	var b []byte
+	if !timersOnly {
+		b, _ = appendName(b, s.keyName)
+		b = binary.BigEndian.AppendUint16(b, dnsClassANY)
+		b = binary.BigEndian.AppendUint32(b, 0)
+		b, _ = appendName(b, s.algorithm)
+	}
+	b = append(b, byte(timeSigned>>40), byte(timeSigned>>32), byte(timeSigned>>24), byte(timeSigned>>16), byte(timeSigned>>8), byte(timeSigned))
+	b = binary.BigEndian.AppendUint16(b, fudge)
+	if !timersOnly {
+		b = binary.BigEndian.AppendUint16(b, tsigError)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(other)))
+		b = append(b, other...)
+	}
+	return b
+}
+
+func (s *tsigSigner) mac(parts ...[]byte) []byte {
+	h := hmac.New(s.hash, s.secret)
+	for _, part := range parts {
+		h.Write(part)
+	}
+	return h.Sum(nil)
+}
+
+// sign appends a TSIG record to a query.
+func (s *tsigSigner) sign(query []byte) ([]byte, error) {
+	s.queryID = binary.BigEndian.Uint16(query)
+	timeSigned := uint64(time.Now().Unix())
+	mac := s.mac(query, s.variables(timeSigned, tsigFudge, 0, nil, false))
+	s.prevMAC = mac
+
+	msg := append([]byte{}, query...)
+	binary.BigEndian.PutUint16(msg[10:], binary.BigEndian.Uint16(msg[10:])+1) // ARCOUNT
+
+	rdata, err := appendName(nil, s.algorithm)
+	if err != nil {
+		return nil, err
+	}
+	rdata = append(rdata, s.variables(timeSigned, tsigFudge, 0, nil, true)...)
+	rdata = binary.BigEndian.AppendUint16(rdata, uint16(len(mac)))
+	rdata = append(rdata, mac...)
+	rdata = binary.BigEndian.AppendUint16(rdata, s.queryID)
+	rdata = binary.BigEndian.AppendUint16(rdata, 0) // error
+	rdata = binary.BigEndian.AppendUint16(rdata, 0) // other length
+
+	if msg, err = appendName(msg, s.keyName); err != nil {
+		return nil, err
+	}
+	msg = binary.BigEndian.AppendUint16(msg, dnsTypeTSIG)
+	msg = binary.BigEndian.AppendUint16(msg, dnsClassANY)
+	msg = binary.BigEndian.AppendUint32(msg, 0)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(len(rdata)))
+	return append(msg, rdata...), nil
+}
+
+// verify checks the TSIG record of a response message, if it carries one.
+func (s *tsigSigner) verify(msg []byte, m *dnsMessage) error {
+	var tsig *dnsRR
+	if n := len(m.Additional); n > 0 && m.Additional[n-1].Type == dnsTypeTSIG {
+		tsig = &m.Additional[n-1]
+	}
+	if tsig == nil {
+		if s.signed == 0 {
+			return fmt.Errorf("the server did not sign its answer, check the TSIG key")
+		}
+		s.unsigned = append(s.unsigned, msg...)
+		s.lastSigned = false
+		return nil
+	}
+
+	r := &rdataReader{msg: msg, off: tsig.RDataOffset, end: tsig.RDataOffset + len(tsig.RData)}
+	algorithm := strings.ToLower(strings.TrimSuffix(r.name(), "."))
+	timers := r.bytes(8)
+	macLength := r.bytes(2)
+	var mac []byte
+	if macLength != nil {
+		mac = r.bytes(int(binary.BigEndian.Uint16(macLength)))
+	}
+	r.bytes(2) // original ID
+	errorField := r.bytes(2)
+	otherLength := r.bytes(2)
+	var other []byte
+	if otherLength != nil {
+		other = r.bytes(int(binary.BigEndian.Uint16(otherLength)))
+	}
+	if r.err != nil {
+		return fmt.Errorf("malformed TSIG record: %v", r.err)
+	}
+
+	switch binary.BigEndian.Uint16(errorField) {
+	case 0:
+	case 16:
+		return fmt.Errorf("the server rejected the TSIG signature (BADSIG)")
+	case 17:
+		return fmt.Errorf("the server does not know TSIG key %q (BADKEY)", s.keyName)
+	case 18:
+		return fmt.Errorf("the server rejected the TSIG time, check the clock (BADTIME)")
+	default:
+		return fmt.Errorf("TSIG error %d", binary.BigEndian.Uint16(errorField))
+	}
+	if algorithm != s.algorithm || strings.ToLower(strings.TrimSuffix(tsig.Name, ".")) != s.keyName {
+		return fmt.Errorf("answer is signed with key %s (%s), expected %s (%s)", tsig.Name, algorithm, s.keyName, s.algorithm)
+	}
+
+	// The MAC covers the message without its TSIG record and with the original ID
+	stripped := append([]byte{}, msg[:tsig.Offset]...)
+	binary.BigEndian.PutUint16(stripped[0:], s.queryID)
+	binary.BigEndian.PutUint16(stripped[10:], binary.BigEndian.Uint16(stripped[10:])-1)
+
+	timeSigned := uint64(binary.BigEndian.Uint16(timers[0:]))<<32 | uint64(binary.BigEndian.Uint32(timers[2:]))
+	fudge := binary.BigEndian.Uint16(timers[6:])
+	prevMAC := binary.BigEndian.AppendUint16(nil, uint16(len(s.prevMAC)))
+	prevMAC = append(prevMAC, s.prevMAC...)
+
+	expected := s.mac(prevMAC, s.unsigned, stripped, s.variables(timeSigned, fudge, 0, other, s.signed > 0))
+	if !hmac.Equal(mac, expected) {
+		return fmt.Errorf("TSIG signature of the answer does not verify")
+	}
+
+	now := uint64(time.Now().Unix())
+	if now > timeSigned+uint64(fudge) || timeSigned > now+uint64(fudge) {
+		return fmt.Errorf("TSIG time of the answer is outside the allowed %ds, check the clock", fudge)
+	}
+
+	s.prevMAC = mac
+	s.unsigned = nil
+	s.signed++
+	s.lastSigned = true
+	return nil
+}
+
+// parseTSIGFlag reads a key given as [algorithm:]name:secret, the form dig -y
+// uses. The algorithm defaults to hmac-sha256.
+func parseTSIGFlag(value string) (*NamedKey, error) {
+	parts := strings.Split(value, ":")
+	key := &NamedKey{Algorithm: "hmac-sha256"}
+	switch len(parts) {
+	case 2:
+		key.Name, key.Secret = parts[0], parts[1]
+	case 3:
+		key.Algorithm, key.Name, key.Secret = strings.ToLower(parts[0]), parts[1], parts[2]
+	default:
+		return nil, fmt.Errorf("expected [algorithm:]name:secret")
+	}
+	if _, exists := tsigHashes[key.Algorithm]; !exists {
+		return nil, fmt.Errorf("unsupported TSIG algorithm %q", key.Algorithm)
+	}
+	if key.Name == "" || key.Secret == "" {
+		return nil, fmt.Errorf("expected [algorithm:]name:secret")
+	}
+	return key, nil
+}
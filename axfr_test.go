@@ -0,0 +1,329 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveAXFR answers one zone transfer on a local port and returns the server
+// address. respond gets the query and returns the bytes to send back, framed
+// with tcpFrame, so a test can also send a cut off stream.
+func serveAXFR(t *testing.T, respond func(query []byte) [][]byte) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+		query, err := readTCPMessage(conn)
+		if err != nil {
+			return
+		}
+		for _, data := range respond(query) {
+			if _, err := conn.Write(data); err != nil {
+				return
+			}
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// tcpFrame prefixes a message with its length.
+func tcpFrame(msg []byte) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, uint16(len(msg))), msg...)
+}
+
+// testRR is an answer record with its owner name already in wire format.
+type testRR struct {
+	owner  []byte
+	rrType uint16
+	rdata  []byte
+}
+
+// testName returns labels followed by a compression pointer to the zone name
+// in the question, which always starts at offset 12.
+func testName(labels ...string) []byte {
+	var b []byte
+	for _, label := range labels {
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0xc0, dnsHeaderLen)
+}
+
+func testSOA(serial uint32) testRR {
+	rdata := append(testName("ns1"), testName("admin")...)
+	for _, value := range []uint32{serial, 86400, 7200, 3600000, 3600} {
+		rdata = binary.BigEndian.AppendUint32(rdata, value)
+	}
+	return testRR{testName(), dnsTypeSOA, rdata}
+}
+
+func testA(owner []byte, address string) testRR {
+	return testRR{owner, 1, net.ParseIP(address).To4()}
+}
+
+// testResponse answers query with records, the header and question copied
+// from the query.
+func testResponse(query []byte, answers ...testRR) []byte {
+	_, end, err := readDNSName(query, dnsHeaderLen)
+	if err != nil {
+		return nil
+	}
+	msg := append([]byte{}, query[:end+4]...)
+	binary.BigEndian.PutUint16(msg[2:], 0x8400) // QR, AA
+	binary.BigEndian.PutUint16(msg[6:], uint16(len(answers)))
+	binary.BigEndian.PutUint16(msg[8:], 0)
+	binary.BigEndian.PutUint16(msg[10:], 0)
+
+	for _, rr := range answers {
+		msg = append(msg, rr.owner...)
+		msg = binary.BigEndian.AppendUint16(msg, rr.rrType)
+		msg = binary.BigEndian.AppendUint16(msg, dnsClassIN)
+		msg = binary.BigEndian.AppendUint32(msg, 3600)
+		msg = binary.BigEndian.AppendUint16(msg, uint16(len(rr.rdata)))
+		msg = append(msg, rr.rdata...)
+	}
+	return msg
+}
+
+// testTransfer is a zone sent as three messages, names compressed against
+// the question.
+func testTransfer(query []byte) [][]byte {
+	return [][]byte{
+		testResponse(query, testSOA(1), testRR{testName(), 2, testName("ns1")}),
+		testResponse(query, testA(testName("ns1"), "192.0.2.1"), testA(testName("www"), "192.0.2.10")),
+		testResponse(query, testSOA(1)),
+	}
+}
+
+var testTransferKey = NamedKey{
+	Name:      "transfer-key",
+	Algorithm: "hmac-sha256",
+	Secret:    base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")),
+}
+
+// testQueryMAC returns the MAC of a signed query.
+func testQueryMAC(query []byte) []byte {
+	m, err := parseDNSMessage(query)
+	if err != nil || len(m.Additional) == 0 {
+		return nil
+	}
+	tsig := m.Additional[len(m.Additional)-1]
+	r := &rdataReader{msg: query, off: tsig.RDataOffset, end: tsig.RDataOffset + len(tsig.RData)}
+	r.name()
+	r.bytes(8)
+	length := r.bytes(2)
+	if length == nil {
+		return nil
+	}
+	return r.bytes(int(binary.BigEndian.Uint16(length)))
+}
+
+// testSignResponse signs a response the way a server does: the first one
+// chained to the query MAC, later ones to the previous MAC and the unsigned
+// messages in between, with only the timers.
+func testSignResponse(msg, prevMAC, unsigned []byte, first bool) (signed, mac []byte) {
+	s, _ := newTSIGSigner(testTransferKey)
+	timeSigned := uint64(time.Now().Unix())
+	prev := binary.BigEndian.AppendUint16(nil, uint16(len(prevMAC)))
+	prev = append(prev, prevMAC...)
+	mac = s.mac(prev, unsigned, msg, s.variables(timeSigned, tsigFudge, 0, nil, !first))
+
+	rdata, _ := appendName(nil, s.algorithm)
+	rdata = append(rdata, s.variables(timeSigned, tsigFudge, 0, nil, true)...)
+	rdata = binary.BigEndian.AppendUint16(rdata, uint16(len(mac)))
+	rdata = append(rdata, mac...)
+	rdata = append(rdata, msg[0], msg[1]) // original ID
+	rdata = binary.BigEndian.AppendUint16(rdata, 0)
+	rdata = binary.BigEndian.AppendUint16(rdata, 0)
+
+	signed = append([]byte{}, msg...)
+	binary.BigEndian.PutUint16(signed[10:], binary.BigEndian.Uint16(signed[10:])+1)
+	signed, _ = appendName(signed, s.keyName)
+	signed = binary.BigEndian.AppendUint16(signed, dnsTypeTSIG)
+	signed = binary.BigEndian.AppendUint16(signed, dnsClassANY)
+	signed = binary.BigEndian.AppendUint32(signed, 0)
+	signed = binary.BigEndian.AppendUint16(signed, uint16(len(rdata)))
+	return append(signed, rdata...), mac
+}
+
+// testSignedTransfer signs the first and last message of testTransfer and
+// leaves the middle one unsigned.
+func testSignedTransfer(query []byte) [][]byte {
+	messages := testTransfer(query)
+	first, mac := testSignResponse(messages[0], testQueryMAC(query), nil, true)
+	last, _ := testSignResponse(messages[2], mac, messages[1], false)
+	return [][]byte{tcpFrame(first), tcpFrame(messages[1]), tcpFrame(last)}
+}
+
+// checkTransferredZone checks the zone of testTransfer came through.
+func checkTransferredZone(t *testing.T, zoneConfig *ZoneConfig) {
+	t.Helper()
+	if zoneConfig.Metadata.Name != "example.com" {
+		t.Errorf("got zone %s, want example.com", zoneConfig.Metadata.Name)
+	}
+	addresses := make(map[string]string)
+	for _, set := range allRRSets(zoneConfig.Spec.Primary) {
+		if set.ARecord != nil {
+			addresses[set.ARecord.Name] = strings.Join(set.ARecord.Values, ",")
+		}
+	}
+	if addresses["ns1"] != "192.0.2.1" || addresses["www"] != "192.0.2.10" {
+		t.Errorf("got A records %v, want ns1 and www", addresses)
+	}
+	if refresh := zoneConfig.Spec.Primary.SOAParameters.Refresh; refresh != 86400 {
+		t.Errorf("got SOA refresh %d, want 86400", refresh)
+	}
+}
+
+func TestTransferZoneMultipleMessages(t *testing.T) {
+	server := serveAXFR(t, func(query []byte) [][]byte {
+		var frames [][]byte
+		for _, msg := range testTransfer(query) {
+			frames = append(frames, tcpFrame(msg))
+		}
+		return frames
+	})
+
+	zoneConfig, err := TransferZone(server, "example.com", nil, newConversionOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTransferredZone(t, zoneConfig)
+}
+
+func TestAXFRCompressedNames(t *testing.T) {
+	server := serveAXFR(t, func(query []byte) [][]byte {
+		// The CNAME points at the owner of the A record, which itself points
+		// at the question
+		mail := len(testResponse(query, testSOA(1)))
+		pointer := []byte{0xc0 | byte(mail>>8), byte(mail)}
+		msg := testResponse(query, testSOA(1), testA(testName("mail"), "192.0.2.25"),
+			testRR{append([]byte{3, 'w', 'w', 'w'}, pointer...), 5, pointer},
+			testSOA(1))
+		return [][]byte{tcpFrame(msg)}
+	})
+
+	lines, err := axfr(server, "example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"example.com. 3600 IN SOA ns1.example.com. admin.example.com. 1 86400 7200 3600000 3600",
+		"mail.example.com. 3600 IN A 192.0.2.25",
+		"www.mail.example.com. 3600 IN CNAME mail.example.com.",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestTransferZoneTSIG(t *testing.T) {
+	server := serveAXFR(t, testSignedTransfer)
+
+	zoneConfig, err := TransferZone(server, "example.com", &testTransferKey, newConversionOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTransferredZone(t, zoneConfig)
+}
+
+func TestTransferZoneTSIGBadMAC(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		corrupt func(frames [][]byte)
+		want    string
+	}{
+		{"first message", func(frames [][]byte) { frames[0][len(frames[0])-10] ^= 0xff }, "does not verify"},
+		{"last message", func(frames [][]byte) { frames[2][len(frames[2])-10] ^= 0xff }, "does not verify"},
+		{"unsigned message", func(frames [][]byte) { frames[1][len(frames[1])-1] ^= 0xff }, "does not verify"},
+		{"last unsigned", func(frames [][]byte) {
+			frames[2] = tcpFrame(testResponse(frames[2][2:], testSOA(1)))
+		}, "not signed"},
+	} {
+		server := serveAXFR(t, func(query []byte) [][]byte {
+			frames := testSignedTransfer(query)
+			test.corrupt(frames)
+			return frames
+		})
+
+		_, err := TransferZone(server, "example.com", &testTransferKey, newConversionOptions())
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.want)
+		}
+	}
+}
+
+func TestTransferZoneUnsignedAnswer(t *testing.T) {
+	server := serveAXFR(t, func(query []byte) [][]byte {
+		var frames [][]byte
+		for _, msg := range testTransfer(query) {
+			frames = append(frames, tcpFrame(msg))
+		}
+		return frames
+	})
+
+	_, err := TransferZone(server, "example.com", &testTransferKey, newConversionOptions())
+	if err == nil || !strings.Contains(err.Error(), "did not sign") {
+		t.Errorf("got error %v, want the unsigned answer rejected", err)
+	}
+}
+
+func TestTransferZoneShortRead(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		respond func(query []byte) [][]byte
+	}{
+		{"closed before the answer", func(query []byte) [][]byte { return nil }},
+		{"cut off length", func(query []byte) [][]byte { return [][]byte{{0}} }},
+		{"cut off message", func(query []byte) [][]byte {
+			return [][]byte{tcpFrame(testTransfer(query)[0])[:30]}
+		}},
+		{"closed mid transfer", func(query []byte) [][]byte {
+			return [][]byte{tcpFrame(testTransfer(query)[0])}
+		}},
+		{"short SOA rdata", func(query []byte) [][]byte {
+			soa := testSOA(1)
+			soa.rdata = soa.rdata[:10]
+			return [][]byte{tcpFrame(testResponse(query, soa))}
+		}},
+	} {
+		server := serveAXFR(t, test.respond)
+
+		if _, err := TransferZone(server, "example.com", nil, newConversionOptions()); err == nil {
+			t.Errorf("%s: transfer succeeded", test.name)
+		}
+	}
+}
+
+// Every message cut short is an error, whatever field the cut falls in.
+func TestParseDNSMessageTruncated(t *testing.T) {
+	query, err := newDNSQuery(1, "example.com", dnsTypeAXFR, dnsClassIN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := testResponse(query, testSOA(1), testA(testName("www"), "192.0.2.10"))
+	if _, err := parseDNSMessage(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	for n := 0; n < len(msg); n++ {
+		if _, err := parseDNSMessage(msg[:n]); err == nil {
+			t.Errorf("message cut to %d of %d bytes parsed", n, len(msg))
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Output formats for -format
+const (
+	FormatJSON      = "json"
+	FormatTerraform = "terraform"
+	FormatYAML      = "yaml"
+)
+
+// File extension and name of each output format
+var (
+	outputExtensions  = map[string]string{FormatJSON: ".json", FormatTerraform: ".tf", FormatYAML: ".yaml"}
+	outputFormatNames = map[string]string{FormatJSON: "JSON", FormatTerraform: "Terraform", FormatYAML: "YAML"}
+)
+
+// Manifest statuses
+const (
+	ZoneStatusConverted = "converted"
+	ZoneStatusSkipped   = "skipped"
+	ZoneStatusFailed    = "failed"
+)
+
+// ManifestEntry records what happened to one zone of a named.conf conversion.
+type ManifestEntry struct {
+	Zone           string `json:"zone"`
+	View           string `json:"view,omitempty"`
+	Type           string `json:"type"`
+	Source         string `json:"source,omitempty"`
+	Output         string `json:"output,omitempty"`
+	Status         string `json:"status"`
+	Message        string `json:"message,omitempty"`
+	Records        int    `json:"records"`
+	SkippedRecords int    `json:"skipped_records"`
+}
+
+// zoneOutputFileName returns the output file name for a zone, qualified with
+// its view as zone@view.json when it is declared in one, with the extension
+// of the output format. The "/" of RFC 2317
+// classless reverse zones cannot appear in a file name.
+func zoneOutputFileName(zoneName, view, format string) string {
+	name := strings.TrimSuffix(zoneName, ".")
+	if view != "" {
+		name += "@" + view
+	}
+	return strings.ReplaceAll(name, "/", "_") + outputExtensions[format]
+}
+
+// writeZoneConfig renders a zone configuration in the output format of opts
+// and writes it to path.
+func writeZoneConfig(zoneConfig *ZoneConfig, path string, opts conversionOptions) error {
+	var output []byte
+	var err error
+	switch opts.Format {
+	case FormatTerraform:
+		output, err = renderTerraform(zoneConfig, opts.Namespace)
+	case FormatYAML:
+		output, err = renderYAML(zoneConfig, opts.Namespace)
+	default:
+		// Marshal the zone configuration to JSON
+		output, err = json.MarshalIndent(zoneConfig, "", "  ")
+		if err != nil {
+			err = fmt.Errorf("error marshaling to JSON: %v", err)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	// Write the output to the specified file
+	if err := os.WriteFile(path, output, 0644); err != nil {
+		return fmt.Errorf("error writing to output file: %v", err)
+	}
+
+	return nil
+}
+
+// convertNamedConf converts every zone declared in a BIND configuration and
+// writes one output per zone plus a manifest.json into opts.OutputDir. Zone
+// files resolve against the options directory, or rootPath when there is none.
+// When views is not empty only the zones of those views are converted.
+func convertNamedConf(namedConfPath, rootPath string, views []string, opts conversionOptions) ([]ManifestEntry, error) {
+	conf, err := ParseNamedConf(namedConfPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, view := range views {
+		if !stringInSlice(view, conf.Views) {
+			return nil, fmt.Errorf("view %q is not defined in %s", view, namedConfPath)
+		}
+	}
+
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %v", err)
+	}
+
+	// $INCLUDE paths inside the zone files are relative to the same directory
+	zoneRoot := conf.Directory
+	if zoneRoot == "" {
+		zoneRoot = rootPath
+	}
+
+	var manifest []ManifestEntry
+	for _, zone := range conf.Zones {
+		if len(views) > 0 && zone.View != "" && !stringInSlice(zone.View, views) {
+			manifest = append(manifest, ManifestEntry{Zone: zone.Name, View: zone.View, Type: zone.Type, Status: ZoneStatusSkipped, Message: "view not selected"})
+			continue
+		}
+		manifest = append(manifest, convertNamedZone(conf, zone, zoneRoot, opts))
+	}
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return manifest, fmt.Errorf("error marshaling manifest: %v", err)
+	}
+	manifestPath := filepath.Join(opts.OutputDir, "manifest.json")
+	if err := os.WriteFile(manifestPath, manifestBytes, 0644); err != nil {
+		return manifest, fmt.Errorf("error writing manifest: %v", err)
+	}
+
+	return manifest, nil
+}
+
+func convertNamedZone(conf *NamedConf, zone NamedZone, zoneRoot string, opts conversionOptions) ManifestEntry {
+	entry := ManifestEntry{Zone: zone.Name, View: zone.View, Type: zone.Type, Source: conf.ZoneFilePath(zone, zoneRoot)}
+
+	switch zone.Type {
+	case "master", "primary", "slave", "secondary":
+	default:
+		entry.Status = ZoneStatusSkipped
+		entry.Message = fmt.Sprintf("zones of type %q are not converted", zone.Type)
+		return entry
+	}
+	if zone.Class != "" && zone.Class != "IN" {
+		entry.Status = ZoneStatusSkipped
+		entry.Message = fmt.Sprintf("only class IN zones can be converted, found class %s", zone.Class)
+		return entry
+	}
+
+	// Secondaries keep transferring from their primaries, the cached file is not read
+	if zone.Type == "slave" || zone.Type == "secondary" {
+		entry.Source = ""
+		zoneConfig, err := secondaryZoneConfig(conf, zone, opts)
+		if err != nil {
+			entry.Status = ZoneStatusFailed
+			entry.Message = err.Error()
+			return entry
+		}
+		return writeManifestZone(entry, zoneConfig, opts)
+	}
+	if opts.TransferServer != "" {
+		entry.Source = "axfr://" + opts.TransferServer + "/" + zone.Name
+	}
+	if entry.Source == "" {
+		entry.Status = ZoneStatusFailed
+		entry.Message = "zone has no file statement"
+		return entry
+	}
+
+	fmt.Printf("Processing %s from %s\n", zone.Name, entry.Source)
+
+	// Every zone is its own conversion, views may share a zone file. Its
+	// records are added to the run's report once it is done.
+	zoneOpts := opts
+	zoneOpts.Zone = newZoneState()
+	var zoneConfig *ZoneConfig
+	var err error
+	if opts.TransferServer != "" {
+		zoneConfig, err = TransferZone(opts.TransferServer, zone.Name, opts.TransferKey, zoneOpts)
+	} else {
+		zoneConfig, err = ParseZoneFile(entry.Source, zone.Name, zoneRoot, zoneOpts)
+	}
+	opts.Zone.add(zoneOpts.Zone)
+	entry.SkippedRecords = len(zoneOpts.Zone.Skipped)
+	if err != nil {
+		entry.Status = ZoneStatusFailed
+		entry.Message = err.Error()
+		return entry
+	}
+
+	entry.Records = countRRSets(zoneConfig.Spec.Primary)
+	return writeManifestZone(entry, zoneConfig, opts)
+}
+
+// writeManifestZone writes a converted zone into opts.OutputDir, pushes it
+// with -push and records the result in its manifest entry.
+func writeManifestZone(entry ManifestEntry, zoneConfig *ZoneConfig, opts conversionOptions) ManifestEntry {
+	entry.Output = filepath.Join(opts.OutputDir, zoneOutputFileName(entry.Zone, entry.View, opts.Format))
+	if err := writeZoneConfig(zoneConfig, entry.Output, opts); err != nil {
+		entry.Status = ZoneStatusFailed
+		entry.Message = err.Error()
+		entry.Output = ""
+		return entry
+	}
+
+	if opts.PushClient != nil {
+		if err := pushConvertedZone(zoneConfig, entry.View, opts); err != nil {
+			entry.Status = ZoneStatusFailed
+			entry.Message = "push failed: " + err.Error()
+			return entry
+		}
+	}
+
+	entry.Status = ZoneStatusConverted
+	return entry
+}
+
+// printManifest prints a one line status per zone.
+func printManifest(manifest []ManifestEntry) {
+	counts := make(map[string]int)
+	for _, entry := range manifest {
+		counts[entry.Status]++
+
+		color := ColorGreen
+		switch entry.Status {
+		case ZoneStatusSkipped:
+			color = ColorYellow
+		case ZoneStatusFailed:
+			color = ColorRed
+		}
+		zone := entry.Zone
+		if entry.View != "" {
+			zone += " in view " + entry.View
+		}
+		fmt.Printf("%s%-9s%s %s (%s)", color, entry.Status, ColorReset, zone, entry.Type)
+		if entry.Output != "" {
+			fmt.Printf(" -> %s", entry.Output)
+			// Secondaries have no records of their own
+			if entry.Source != "" {
+				fmt.Printf(", %d RR sets", entry.Records)
+			}
+		}
+		if entry.Message != "" {
+			fmt.Printf(": %s", entry.Message)
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("%d zone(s): %d converted, %d skipped, %d failed\n", len(manifest), counts[ZoneStatusConverted], counts[ZoneStatusSkipped], counts[ZoneStatusFailed])
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestZoneOutputFileName(t *testing.T) {
+	for _, test := range []struct {
+		zone, view, format string
+		want               string
+	}{
+		{"example.com.", "", FormatJSON, "example.com.json"},
+		{"example.com", "internal", FormatJSON, "example.com@internal.json"},
+		{"64/26.2.0.192.in-addr.arpa", "internal", FormatJSON, "64_26.2.0.192.in-addr.arpa@internal.json"},
+	} {
+		if got := zoneOutputFileName(test.zone, test.view, test.format); got != test.want {
+			t.Errorf("zoneOutputFileName(%q, %q, %q) = %q, want %q", test.zone, test.view, test.format, got, test.want)
+		}
+	}
+}
+
+// A split-horizon zone is converted once per view from that view's file, and
+// -view limits the conversion to the selected views.
+func TestConvertNamedConfViews(t *testing.T) {
+	zone := func(address string) string {
+		return `$TTL 3600
+@ IN SOA ns1.example.com. admin.example.com. 1 86400 7200 3600000 3600
+@ IN NS ns1.example.com.
+www IN A ` + address + "\n"
+	}
+	dir := writeTestFiles(t, map[string]string{
+		"named.conf": `view "internal" { zone "example.com" { type master; file "internal.zone"; }; };
+view "external" { zone "example.com" { type master; file "external.zone"; }; };
+`,
+		"internal.zone": zone("10.0.0.1"),
+		"external.zone": zone("192.0.2.1"),
+	})
+
+	opts := newConversionOptions()
+	opts.OutputDir = filepath.Join(dir, "out")
+	manifest, err := convertNamedConf(filepath.Join(dir, "named.conf"), dir, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest) != 2 {
+		t.Fatalf("got %d manifest entries, want 2: %+v", len(manifest), manifest)
+	}
+	for _, test := range []struct{ view, address string }{{"internal", "10.0.0.1"}, {"external", "192.0.2.1"}} {
+		path := filepath.Join(opts.OutputDir, "example.com@"+test.view+".json")
+		zoneConfig, err := readZoneConfig(path)
+		if err != nil {
+			t.Errorf("%s: %v", test.view, err)
+			continue
+		}
+		if set := findRRSet(t, zoneConfig, "A", "www"); set.ARecord.Values[0] != test.address {
+			t.Errorf("%s: got www %v, want %s", test.view, set.ARecord.Values, test.address)
+		}
+	}
+
+	opts.OutputDir = filepath.Join(dir, "selected")
+	manifest, err = convertNamedConf(filepath.Join(dir, "named.conf"), dir, []string{"external"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest[0].Status != ZoneStatusSkipped || manifest[1].Status != ZoneStatusConverted {
+		t.Errorf("got manifest %+v, want only the external view converted", manifest)
+	}
+	if _, err := os.Stat(filepath.Join(opts.OutputDir, "example.com@internal.json")); !os.IsNotExist(err) {
+		t.Errorf("internal view written: %v", err)
+	}
+
+	if _, err := convertNamedConf(filepath.Join(dir, "named.conf"), dir, []string{"guest"}, opts); err == nil {
+		t.Error("unknown view accepted")
+	}
+}
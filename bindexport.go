@@ -0,0 +1,286 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// bindRecord is one record of an XC zone in master file form.
+type bindRecord struct {
+	Owner string // relative to the zone apex, "@" for the apex
+	TTL   int
+	Type  string
+	RData string
+}
+
+// Order records of one owner are written in
+var bindTypeOrder = map[string]int{
+	"SOA": 0, "NS": 1, "DS": 2, "A": 3, "AAAA": 4, "CNAME": 5, "MX": 6, "TXT": 7,
+	"SRV": 8, "CAA": 9, "PTR": 10, "NAPTR": 11, "SSHFP": 12, "TLSA": 13,
+}
+
+// readZoneConfig loads a ZoneConfig JSON file.
+func readZoneConfig(path string) (*ZoneConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+
+	zoneConfig := &ZoneConfig{}
+	if err := json.Unmarshal(data, zoneConfig); err != nil {
+		return nil, fmt.Errorf("%s is not a zone configuration: %v", path, err)
+	}
+	if zoneConfig.Metadata.Name == "" {
+		return nil, fmt.Errorf("%s has no metadata.name", path)
+	}
+	return zoneConfig, nil
+}
+
+// enumNumber looks up the number behind an XC enum name.
+func enumNumber(enum map[int]string, name string) (int, error) {
+	for number, enumName := range enum {
+		if strings.EqualFold(enumName, name) {
+			return number, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown value %q", name)
+}
+
+// absoluteTarget writes a converted domain name, kept without its trailing
+// dot, as an absolute master file name.
+func absoluteTarget(name string) string {
+	return strings.TrimSuffix(name, ".") + "."
+}
+
+// quoteTXT writes a TXT value as quoted character-strings of at most 255
+// bytes each.
+func quoteTXT(value string) string {
+	var parts []string
+	for len(value) > 255 {
+		parts = append(parts, quoteCharacterString([]byte(value[:255])))
+		value = value[255:]
+	}
+	parts = append(parts, quoteCharacterString([]byte(value)))
+	return strings.Join(parts, " ")
+}
+
+// zoneConfigRecords lists every record of a primary zone in master file form,
+// from the default and the named RR set groups, ordered by owner and type. RR sets without a TTL get defaultTTL.
+func zoneConfigRecords(zoneConfig *ZoneConfig, defaultTTL int) ([]bindRecord, error) {
+	if zoneConfig.Spec.Primary == nil {
+		return nil, fmt.Errorf("zone %s is not a primary zone, it has no records", zoneConfig.Metadata.Name)
+	}
+
+	var records []bindRecord
+	for i, set := range allRRSets(zoneConfig.Spec.Primary) {
+		ttl := set.TTL
+		if ttl == 0 {
+			ttl = defaultTTL
+		}
+		add := func(name, rrType string, rdata string) {
+			owner := name
+			if owner == "" {
+				owner = "@"
+			}
+			records = append(records, bindRecord{Owner: owner, TTL: ttl, Type: rrType, RData: rdata})
+		}
+
+		switch {
+		case set.ARecord != nil:
+			for _, value := range set.ARecord.Values {
+				add(set.ARecord.Name, "A", value)
+			}
+		case set.AAAARecord != nil:
+			for _, value := range set.AAAARecord.Values {
+				add(set.AAAARecord.Name, "AAAA", value)
+			}
+		case set.NSRecord != nil:
+			for _, value := range set.NSRecord.Values {
+				add(set.NSRecord.Name, "NS", absoluteTarget(value))
+			}
+		case set.PTRRecord != nil:
+			for _, value := range set.PTRRecord.Values {
+				add(set.PTRRecord.Name, "PTR", absoluteTarget(value))
+			}
+		case set.CNAMERecord != nil:
+			add(set.CNAMERecord.Name, "CNAME", absoluteTarget(set.CNAMERecord.Value))
+		case set.MXRecord != nil:
+			for _, value := range set.MXRecord.Values {
+				add(set.MXRecord.Name, "MX", fmt.Sprintf("%d %s", value.Priority, absoluteTarget(value.Value)))
+			}
+		case set.TXTRecord != nil:
+			for _, value := range set.TXTRecord.Values {
+				add(set.TXTRecord.Name, "TXT", quoteTXT(value))
+			}
+		case set.SRVRecord != nil:
+			for _, value := range set.SRVRecord.Values {
+				add(set.SRVRecord.Name, "SRV", fmt.Sprintf("%d %d %d %s", value.Priority, value.Weight, value.Port, absoluteTarget(value.Target)))
+			}
+		case set.CAARecord != nil:
+			for _, value := range set.CAARecord.Values {
+				add(set.CAARecord.Name, "CAA", fmt.Sprintf("%d %s %s", value.Flags, value.Tag, quoteCharacterString([]byte(value.Value))))
+			}
+		case set.NAPTRRecord != nil:
+			for _, value := range set.NAPTRRecord.Values {
+				replacement := "."
+				if value.Replacement != "." && value.Replacement != "" {
+					replacement = absoluteTarget(value.Replacement)
+				}
+				add(set.NAPTRRecord.Name, "NAPTR", fmt.Sprintf("%d %d %s %s %s %s", value.Order, value.Preference,
+					quoteCharacterString([]byte(value.Flags)), quoteCharacterString([]byte(value.Service)), quoteCharacterString([]byte(value.Regexp)), replacement))
+			}
+		case set.SSHFPRecord != nil:
+			for _, value := range set.SSHFPRecord.Values {
+				algorithm, err := enumNumber(sshfpAlgorithms, value.Algorithm)
+				if err != nil {
+					return nil, fmt.Errorf("SSHFP %s: algorithm: %v", set.SSHFPRecord.Name, err)
+				}
+				switch {
+				case value.SHA1Fingerprint != nil:
+					add(set.SSHFPRecord.Name, "SSHFP", fmt.Sprintf("%d 1 %s", algorithm, strings.ToUpper(value.SHA1Fingerprint.Fingerprint)))
+				case value.SHA256Fingerprint != nil:
+					add(set.SSHFPRecord.Name, "SSHFP", fmt.Sprintf("%d 2 %s", algorithm, strings.ToUpper(value.SHA256Fingerprint.Fingerprint)))
+				default:
+					return nil, fmt.Errorf("SSHFP %s has no fingerprint", set.SSHFPRecord.Name)
+				}
+			}
+		case set.TLSARecord != nil:
+			for _, value := range set.TLSARecord.Values {
+				usage, err := enumNumber(tlsaCertificateUsages, value.CertificateUsage)
+				if err != nil {
+					return nil, fmt.Errorf("TLSA %s: certificate usage: %v", set.TLSARecord.Name, err)
+				}
+				selector, err := enumNumber(tlsaSelectors, value.Selector)
+				if err != nil {
+					return nil, fmt.Errorf("TLSA %s: selector: %v", set.TLSARecord.Name, err)
+				}
+				matchingType, err := enumNumber(tlsaMatchingTypes, value.MatchingType)
+				if err != nil {
+					return nil, fmt.Errorf("TLSA %s: matching type: %v", set.TLSARecord.Name, err)
+				}
+				add(set.TLSARecord.Name, "TLSA", fmt.Sprintf("%d %d %d %s", usage, selector, matchingType, strings.ToUpper(value.CertificateAssociationData)))
+			}
+		case set.DSRecord != nil:
+			for _, value := range set.DSRecord.Values {
+				algorithm, err := enumNumber(dsKeyAlgorithms, value.DSKeyAlgorithm)
+				if err != nil {
+					return nil, fmt.Errorf("DS %s: algorithm: %v", set.DSRecord.Name, err)
+				}
+				var digestType int
+				var digest string
+				switch {
+				case value.SHA1Digest != nil:
+					digestType, digest = 1, value.SHA1Digest.Digest
+				case value.SHA256Digest != nil:
+					digestType, digest = 2, value.SHA256Digest.Digest
+				case value.SHA384Digest != nil:
+					digestType, digest = 4, value.SHA384Digest.Digest
+				default:
+					return nil, fmt.Errorf("DS %s has no digest", set.DSRecord.Name)
+				}
+				add(set.DSRecord.Name, "DS", fmt.Sprintf("%d %d %d %s", value.KeyTag, algorithm, digestType, strings.ToUpper(digest)))
+			}
+		default:
+			return nil, fmt.Errorf("RR set %d of zone %s has no record", i, zoneConfig.Metadata.Name)
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return lessBindRecord(records[i], records[j])
+	})
+
+	return records, nil
+}
+
+// lessBindRecord orders records by owner, the apex first, then type and data.
+func lessBindRecord(a, b bindRecord) bool {
+	if a.Owner != b.Owner {
+		if a.Owner == "@" || b.Owner == "@" {
+			return a.Owner == "@"
+		}
+		return a.Owner < b.Owner
+	}
+	if bindTypeOrder[a.Type] != bindTypeOrder[b.Type] {
+		return bindTypeOrder[a.Type] < bindTypeOrder[b.Type]
+	}
+	return a.RData < b.RData
+}
+
+// writeBINDZone writes a primary zone as a canonical BIND zone file. XC keeps
+// no primary server, contact or serial, so the SOA uses the first apex NS
+// record, hostmaster@zone and serial 1.
+func writeBINDZone(zoneConfig *ZoneConfig, w io.Writer) error {
+	soa := SOAParameters{}
+	if zoneConfig.Spec.Primary != nil {
+		soa = zoneConfig.Spec.Primary.SOAParameters
+	}
+	defaultTTL := soa.TTL
+	if defaultTTL <= 0 {
+		defaultTTL = defaultTTLValue
+	}
+
+	records, err := zoneConfigRecords(zoneConfig, defaultTTL)
+	if err != nil {
+		return err
+	}
+
+	zone := strings.TrimSuffix(zoneConfig.Metadata.Name, ".")
+	primaryServer := "ns1." + zone + "."
+	for _, record := range records {
+		if record.Owner == "@" && record.Type == "NS" {
+			primaryServer = record.RData
+			break
+		}
+	}
+
+	fmt.Fprintf(w, "; %s converted from XC DNS\n", zone)
+	fmt.Fprintf(w, "$ORIGIN %s.\n", zone)
+	fmt.Fprintf(w, "$TTL %d\n", defaultTTL)
+	fmt.Fprintf(w, "@\t%d\tIN\tSOA\t%s hostmaster.%s. (\n", defaultTTL, primaryServer, zone)
+	fmt.Fprintf(w, "\t\t\t\t1 ; serial\n")
+	fmt.Fprintf(w, "\t\t\t\t%d ; refresh\n", soa.Refresh)
+	fmt.Fprintf(w, "\t\t\t\t%d ; retry\n", soa.Retry)
+	fmt.Fprintf(w, "\t\t\t\t%d ; expire\n", soa.Expire)
+	fmt.Fprintf(w, "\t\t\t\t%d ) ; negative TTL\n", soa.NegativeTTL)
+
+	lastOwner := "@"
+	for _, record := range records {
+		// Repeated owners are left blank like most hand written zones
+		owner := record.Owner
+		if owner == lastOwner {
+			owner = ""
+		}
+		lastOwner = record.Owner
+
+		ttl := ""
+		if record.TTL != defaultTTL {
+			ttl = strconv.Itoa(record.TTL)
+		}
+		fmt.Fprintf(w, "%s\t%s\tIN\t%s\t%s\n", owner, ttl, record.Type, record.RData)
+	}
+
+	return nil
+}
+
+// exportBINDZone converts a ZoneConfig JSON file back to a zone file.
+func exportBINDZone(inputPath, outputPath string) error {
+	zoneConfig, err := readZoneConfig(inputPath)
+	if err != nil {
+		return err
+	}
+
+	var zoneFile strings.Builder
+	if err := writeBINDZone(zoneConfig, &zoneFile); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(outputPath, []byte(zoneFile.String()), 0644); err != nil {
+		return fmt.Errorf("error writing to output file: %v", err)
+	}
+	return nil
+}
@@ -1,155 +1,68 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
 	"flag"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"regexp"
-	"sort"
 	"strconv"
 	"strings"
 )
 
-var processedFiles = make(map[string]bool)
-var lastSeenCNAMEHostname string = ""
+const defaultTTLValue = 300 // Define a constant for the default TTL
 
-// This does nothing yet, I want to move the parsing of records into individual functions to account for better handling, so placeholder for future release
-
-func processARecord(parts []string, lastHostname string) (DNSRecord, string, error) {
-	var dnsRecord DNSRecord
-
-	// Initial assumptions
-	var recordHostname string
-	var values []string
-	var description string
-
-	// Determine hostname and TTL
-	if !isInt(parts[0]) && parts[0] != "IN" {
-		recordHostname = parts[0]
-		ttl, err := strconv.Atoi(parts[1])
-		if err != nil {
-			return DNSRecord{}, lastHostname, fmt.Errorf("invalid TTL: %v", err)
-		}
-		dnsRecord.TTL = ttl
-		values = append(values, parts[3]) // Assuming the value is always the fourth part
-	} else {
-		recordHostname = lastHostname      // Use the last seen hostname if current is omitted
-		ttl, err := strconv.Atoi(parts[0]) // Assuming TTL is the first part if hostname is omitted
-		if err != nil {
-			return DNSRecord{}, lastHostname, fmt.Errorf("invalid TTL: %v", err)
-		}
-		dnsRecord.TTL = ttl
-		values = append(values, parts[2]) // Value is the third part if hostname is omitted
+func processSOA(rdata []string, ttl int, soaParams *SOAParameters) error {
+	// rdata is: <mname> <rname> <serial> <refresh> <retry> <expire> <minimum>
+	if len(rdata) < 7 {
+		return fmt.Errorf("invalid SOA record format: expected 7 fields, found %d", len(rdata))
 	}
 
-	// Parse description if it exists
-	for _, part := range parts {
-		if strings.HasPrefix(part, ";") {
-			//descriptionIndex := strings.Index(line, ";")
-			//description = strings.TrimSpace(line[descriptionIndex+1:])
-			break
+	// Refresh, retry, expire and minimum, in that order
+	var timers [4]int
+	for i := range timers {
+		value, err := extractSOAValue(rdata[3+i])
+		if err != nil {
+			return err
 		}
+		timers[i] = value
 	}
 
-	dnsRecord = DNSRecord{
-		TTL:         86400, // Or determine TTL differently
-		ARecord:     &ARecord{Values: values},
-		Description: description,
-	}
-
-	return dnsRecord, recordHostname, nil // Return the updated lastHostname
-}
+	// Values XC does not accept are raised by clampSOAParameters
+	soaParams.Refresh = timers[0]     // Refresh period
+	soaParams.Retry = timers[1]       // Retry period
+	soaParams.Expire = timers[2]      // Expire time
+	soaParams.NegativeTTL = timers[3] // Minimum TTL
 
-func processSOA(parts []string, soaParams *SOAParameters) {
-	// Simplified example: Extract values assuming parts are in expected positions
-	soaParams.Refresh = extractSOAValue(parts[3]) // Refresh period
-	if soaParams.Refresh < 3600 {
-		soaParams.Refresh = 86400
-	}
-	soaParams.Retry = extractSOAValue(parts[4]) // Retry period
-	if soaParams.Retry < 7200 {
-		soaParams.Retry = 7200
-	}
-	soaParams.Expire = extractSOAValue(parts[5]) // Expire time
-	if soaParams.Expire < soaParams.Refresh+soaParams.Retry {
-		soaParams.Expire = 3600000
-	}
-	soaParams.NegativeTTL = extractSOAValue(parts[6]) // Minimum TTL
-
-	// Assuming TTL is set at the start of the SOA record
-	ttl, _ := strconv.Atoi(strings.Fields(parts[0])[1])
+	// TTL of the SOA record itself
 	soaParams.TTL = ttl
-}
 
-func processCNAME(line string, cnameRecordsMap map[string]*CNAMERecord, origin string, customOrigin string) error {
-	// Normalize the line by converting tabs to spaces and trimming extra spaces
-	normalizedLine := strings.Join(strings.Fields(strings.ReplaceAll(line, "\t", " ")), " ")
-
-	// Split the line into parts
-	parts := strings.Split(normalizedLine, " ")
-
-	// Find index of "IN" and "CNAME" to determine structure
-	inIndex := -1
-	cnameIndex := -1
-	for i, part := range parts {
-		if part == "IN" {
-			inIndex = i
-		} else if part == "CNAME" {
-			cnameIndex = i
-			break
-		}
-	}
-
-	// Validate indices
-	if inIndex == -1 || cnameIndex == -1 || cnameIndex != inIndex+1 {
-		return fmt.Errorf("invalid CNAME record format: missing 'IN CNAME'")
-	}
-
-	// Determine the hostname and value
-	hostname := parts[0]
-	if hostname == "@" {
-		//return fmt.Errorf("Warning '@' is not a permitted hostname [%s], skipping record: %s\n", origin, line)
-		hostname = origin
-	} else if hostname == "" {
-		// Use the last seen hostname if the current line does not specify one
-		hostname = lastSeenCNAMEHostname
-	} else {
-		// Trim and sanitize the hostname
-		hostname = strings.TrimSuffix(strings.TrimSpace(hostname), ".")
-		lastSeenCNAMEHostname = hostname // Update the last seen hostname
-	}
-
-	// Check if hostname ends with the origin and remove it accordingly
-	if strings.HasSuffix(hostname, origin) {
-		// Subtract the length of origin from hostname to remove it
-		hostname = hostname[:len(hostname)-len(origin)]
-		// Optionally, remove any now-trailing dot from hostname
-		hostname = strings.TrimSuffix(hostname, ".")
-	}
+	return nil
+}
 
-	valueIndex := cnameIndex + 1
-	if valueIndex >= len(parts) {
+// processCNAME adds a CNAME for hostname (relative to the zone apex) to
+// cnameRecordsMap. Relative targets are qualified with recordOrigin, the
+// $ORIGIN in effect where the record was written.
+func processCNAME(hostname, target, recordOrigin string, cnameRecordsMap map[string]*CNAMERecord, origin string, customOrigin string) error {
+	value := strings.TrimSpace(target)
+	if value == "" {
 		return fmt.Errorf("invalid CNAME record format: missing value")
 	}
-	isFQDN := false
-	value := strings.TrimSpace(parts[valueIndex])
-	value = strings.TrimSuffix(value, ".") // Ensure value does not end with a dot
-	value = strings.TrimSuffix(value, ".") // why it no good?
 
-	if !strings.Contains(value, ".") && !strings.Contains(value, origin) {
+	isFQDN := false
+	if value == "@" {
+		value = recordOrigin
+	} else if !strings.HasSuffix(value, ".") {
 		//add origin to value
-		tmpValue := value + "." + origin
-		value = tmpValue
+		value = value + "." + recordOrigin
 	}
+	value = strings.TrimSuffix(value, ".") // Ensure value does not end with a dot
 
 	value, isFQDN = ensureFQDN(value, customOrigin)
 
 	if !isFQDN {
-		fmt.Printf(ColorRed+"Warning:"+ColorYellow+" Cannot Map, [%s.%s] not importing:"+ColorReset+" %s\n", origin, customOrigin, line)
-		return nil
+		return fmt.Errorf("cannot map CNAME target %s to an FQDN in [%s.%s]", value, origin, customOrigin)
 	}
 
 	// Special use-case to skip a record if the hostname or value ends with .hsep
@@ -160,8 +73,7 @@ func processCNAME(line string, cnameRecordsMap map[string]*CNAMERecord, origin s
 
 	// Process the record
 	if _, exists := cnameRecordsMap[hostname]; exists {
-		fmt.Printf(ColorRed+"Warning:"+ColorYellow+" Duplicate CNAME record for hostname '%s', skipping:"+ColorReset+" %s\n", hostname, line)
-		return nil
+		return fmt.Errorf("duplicate CNAME record for hostname '%s'", hostname)
 	} else {
 		// Create a new CNAMERecord for this hostname
 		cnameRecordsMap[hostname] = &CNAMERecord{
@@ -173,6 +85,34 @@ func processCNAME(line string, cnameRecordsMap map[string]*CNAMERecord, origin s
 	return nil
 }
 
+// processCAA parses CAA rdata, <flags> <tag> <value>, as defined in RFC 8659.
+func processCAA(rdata []string) (CAAValue, error) {
+	if len(rdata) != 3 {
+		return CAAValue{}, fmt.Errorf("invalid CAA record format: expected <flags> <tag> <value>")
+	}
+
+	flags, err := strconv.Atoi(rdata[0])
+	if err != nil || flags < 0 || flags > 255 {
+		return CAAValue{}, fmt.Errorf("invalid CAA flags %q", rdata[0])
+	}
+
+	tag := strings.ToLower(rdata[1])
+	value := rdata[2]
+
+	switch tag {
+	case "issue", "issuewild":
+		// An empty value is allowed and forbids issuance: 0 issue ";"
+	case "iodef":
+		if !strings.HasPrefix(value, "mailto:") && !strings.HasPrefix(value, "https://") && !strings.HasPrefix(value, "http://") {
+			return CAAValue{}, fmt.Errorf("invalid CAA iodef value %q, expected a mailto: or http(s):// URL", value)
+		}
+	default:
+		return CAAValue{}, fmt.Errorf("unsupported CAA tag %q, XC accepts issue, issuewild and iodef", rdata[1])
+	}
+
+	return CAAValue{Flags: flags, Tag: tag, Value: value}, nil
+}
+
 // Helper function to check if a slice contains a given string
 func contains(slice []string, str string) bool {
 	for _, v := range slice {
@@ -183,59 +123,53 @@ func contains(slice []string, str string) bool {
 	return false
 }
 
-func extractSOAValue(part string) int {
-	// Remove non-numeric characters
-	numericPart := strings.TrimFunc(part, func(r rune) bool {
-		return !('0' <= r && r <= '9')
-	})
-	value, _ := strconv.Atoi(numericPart)
-	return value
-}
-
-func isValidDNSRecord(dnsRecord DNSRecord) bool {
-
-	if dnsRecord.ARecord != nil &&
-		dnsRecord.ARecord.Name != "" &&
-		!isInt(dnsRecord.ARecord.Name) &&
-		len(dnsRecord.ARecord.Values) > 0 {
-		return true
+// extractSOAValue parses an SOA timer field, which accepts the same unit
+// syntax as a TTL (e.g. 2h or 1w).
+func extractSOAValue(part string) (int, error) {
+	value, err := parseTTL(part)
+	if err != nil {
+		return 0, fmt.Errorf("invalid SOA timer value %q", part)
 	}
-
-	return dnsRecord.ARecord != nil ||
-		dnsRecord.CNAMERecord != nil ||
-		dnsRecord.MXRecord != nil ||
-		dnsRecord.TXTRecord != nil ||
-		dnsRecord.AAAARecord != nil ||
-		dnsRecord.NSRecord != nil ||
-		dnsRecord.SRVRecord != nil
+	return value, nil
 }
 
-func parseTTL(ttlStr string) (int, error) {
-	// Enhanced regex pattern to capture numbers followed by an optional time unit (day, hour, minute)
-	ttlPattern := regexp.MustCompile(`^(\d+)([dhmDHM]?)$`)
-	matches := ttlPattern.FindStringSubmatch(ttlStr)
+// ttlPattern matches a TTL in BIND syntax, see parseTTL.
+var ttlPattern = regexp.MustCompile(`^(?:\d+[wdhmsWDHMS])*\d*$`)
 
-	if matches == nil {
+// parseTTL parses a TTL in BIND syntax: a plain number of seconds, or one or
+// more number/unit pairs such as 1w2d3h30m10s. Units are w, d, h, m and s in
+// either case, and a trailing number without a unit counts as seconds.
+func parseTTL(ttlStr string) (int, error) {
+	if ttlStr == "" || !ttlPattern.MatchString(ttlStr) {
 		return 0, fmt.Errorf("invalid TTL format: %s", ttlStr)
 	}
 
-	// Parse the integer part of the TTL
-	ttlValue, err := strconv.Atoi(matches[1])
-	if err != nil {
-		return 0, fmt.Errorf("invalid TTL value: %s", matches[1])
+	unitSeconds := map[byte]int64{'w': 7 * 24 * 3600, 'd': 24 * 3600, 'h': 3600, 'm': 60, 's': 1}
+
+	var total, number int64
+	for i := 0; i < len(ttlStr); i++ {
+		c := ttlStr[i]
+		if isDigit(c) {
+			number = number*10 + int64(c-'0')
+			if number > math.MaxInt32 {
+				return 0, fmt.Errorf("TTL value out of range: %s", ttlStr)
+			}
+			continue
+		}
+		// Adjust TTL based on the time unit, considering both uppercase and lowercase
+		total += number * unitSeconds[c|0x20]
+		number = 0
+		if total > math.MaxInt32 {
+			return 0, fmt.Errorf("TTL value out of range: %s", ttlStr)
+		}
 	}
+	total += number
 
-	// Adjust TTL based on the time designation (if present), considering both uppercase and lowercase
-	switch strings.ToUpper(matches[2]) {
-	case "D":
-		ttlValue *= 24 * 3600
-	case "H":
-		ttlValue *= 3600
-	case "M":
-		ttlValue *= 60
+	if total > math.MaxInt32 {
+		return 0, fmt.Errorf("TTL value out of range: %s", ttlStr)
 	}
 
-	return ttlValue, nil
+	return int(total), nil
 }
 
 func isInt(s string) bool {
@@ -251,69 +185,18 @@ func stringInSlice(a string, list []string) bool {
 	return false
 }
 
-func recordKey(record DNSRecord) string {
-	var builder strings.Builder
-
-	// Use different structuring depending on the type of DNS record
-	if record.ARecord != nil {
-		builder.WriteString("A:")
-		builder.WriteString(record.ARecord.Name)
-	} else if record.SRVRecord != nil {
-		builder.WriteString("SRV:")
-		builder.WriteString(record.SRVRecord.Name)
-		for _, value := range record.SRVRecord.Values {
-			builder.WriteString(fmt.Sprintf(":%d:%d:%d:%s", value.Priority, value.Weight, value.Port, value.Target))
-		}
-	} else if record.MXRecord != nil {
-		builder.WriteString("MX:")
-		for _, value := range *record.MXRecord {
-			builder.WriteString(fmt.Sprintf(":%d:%s", value.Priority, value.Value))
-		}
-	} else if record.TXTRecord != nil {
-		builder.WriteString("TXT:")
-		builder.WriteString(record.TXTRecord.Name)
-		// Sort the values of the TXT record to ensure consistent order
-		sortedValues := make([]string, len(record.TXTRecord.Values))
-		copy(sortedValues, record.TXTRecord.Values)
-		sort.Strings(sortedValues)
-		builder.WriteString(":")
-		builder.WriteString(strings.Join(sortedValues, ";"))
-	} else if record.CNAMERecord != nil {
-		builder.WriteString(fmt.Sprintf("CNAME:%s:%s", record.CNAMERecord.Name, record.CNAMERecord.Value))
-	} else if record.CAARecord != nil {
-		builder.WriteString(fmt.Sprintf("CAA:%s:%s:%s:%s", record.CAARecord.Name, record.CAARecord.Flags, record.CAARecord.Tag, record.CAARecord.Value))
-	} else if record.NSRecord != nil {
-		builder.WriteString("NS:")
-		builder.WriteString(record.NSRecord.Name)
-		for _, value := range record.NSRecord.Values {
-			builder.WriteString(fmt.Sprintf(":%s", value))
-		}
-	} else if record.AAAARecord != nil {
-		builder.WriteString("AAAA:")
-		builder.WriteString(record.AAAARecord.Name)
-		for _, value := range record.AAAARecord.Values {
-			builder.WriteString(fmt.Sprintf(":%s", value))
-		}
-	}
-
-	// Include TTL and description in the key to differentiate records with different TTLs or descriptions
-	builder.WriteString(fmt.Sprintf("TTL:%d:Desc:%s", record.TTL, record.Description))
-
-	return builder.String()
-}
-
 func deduplicateAndMergeDNSRecords(records []DNSRecord) []DNSRecord {
 	mergedRecords := make([]DNSRecord, 0)
-	recordMap := make(map[string]*DNSRecord)
+	recordIndex := make(map[string]int) // Index into mergedRecords, a pointer to the loop variable would be overwritten
 
 	for _, record := range records {
 		key := recordKeyForMerging(record)
-		if existingRecord, found := recordMap[key]; found {
+		if i, found := recordIndex[key]; found {
 			// Merge values if the record supports it and is not a duplicate
-			mergeRecordValues(existingRecord, &record)
+			mergeRecordValues(&mergedRecords[i], &record)
 		} else {
 			// If not found, add record to map and list
-			recordMap[key] = &record
+			recordIndex[key] = len(mergedRecords)
 			mergedRecords = append(mergedRecords, record)
 		}
 	}
@@ -337,6 +220,20 @@ func recordKeyForMerging(record DNSRecord) string {
 		key = fmt.Sprintf("NS-%s", record.NSRecord.Name)
 	} else if record.SRVRecord != nil {
 		key = fmt.Sprintf("SRV-%s", record.SRVRecord.Name)
+	} else if record.CAARecord != nil {
+		key = fmt.Sprintf("CAA-%s", record.CAARecord.Name)
+	} else if record.MXRecord != nil {
+		key = fmt.Sprintf("MX-%s", record.MXRecord.Name)
+	} else if record.PTRRecord != nil {
+		key = fmt.Sprintf("PTR-%s", record.PTRRecord.Name)
+	} else if record.NAPTRRecord != nil {
+		key = fmt.Sprintf("NAPTR-%s", record.NAPTRRecord.Name)
+	} else if record.SSHFPRecord != nil {
+		key = fmt.Sprintf("SSHFP-%s", record.SSHFPRecord.Name)
+	} else if record.TLSARecord != nil {
+		key = fmt.Sprintf("TLSA-%s", record.TLSARecord.Name)
+	} else if record.DSRecord != nil {
+		key = fmt.Sprintf("DS-%s", record.DSRecord.Name)
 	} // Add other record types as needed
 	return key
 }
@@ -355,125 +252,99 @@ func mergeRecordValues(existingRecord, newRecord *DNSRecord) {
 			}
 		}
 	}
-	// Repeat for AAAA, TXT, etc., with appropriate adjustments
+	if existingRecord.AAAARecord != nil && newRecord.AAAARecord != nil {
+		existingRecord.AAAARecord.Values = appendMissing(existingRecord.AAAARecord.Values, newRecord.AAAARecord.Values)
+	}
+	if existingRecord.TXTRecord != nil && newRecord.TXTRecord != nil {
+		existingRecord.TXTRecord.Values = appendMissing(existingRecord.TXTRecord.Values, newRecord.TXTRecord.Values)
+		if existingRecord.Description == "" {
+			existingRecord.Description = newRecord.Description
+		}
+	}
+	if existingRecord.NSRecord != nil && newRecord.NSRecord != nil {
+		existingRecord.NSRecord.Values = appendMissing(existingRecord.NSRecord.Values, newRecord.NSRecord.Values)
+	}
 }
 
-func processZoneBlock(zoneLines []string) (string, string, error) {
-	var zoneFilePath, domainName string
-	for _, line := range zoneLines {
-		if strings.HasPrefix(line, "zone") {
-			// Extract the domain name
-			matches := regexp.MustCompile(`zone\s+"([^"]+)"`).FindStringSubmatch(line)
-			if len(matches) > 1 {
-				domainName = matches[1]
-			}
-		} else if strings.Contains(line, "file") {
-			// Extract the file path
-			matches := regexp.MustCompile(`file\s+"([^"]+)"`).FindStringSubmatch(line)
-			if len(matches) > 1 {
-				zoneFilePath = matches[1]
-			}
+// appendMissing appends the values not already present in existing.
+func appendMissing(existing, values []string) []string {
+	for _, value := range values {
+		if !contains(existing, value) {
+			existing = append(existing, value)
 		}
 	}
-
-	return domainName, zoneFilePath, nil
+	return existing
 }
 
-func processIncludeDirective(filePath, includeOrigin string, rootPath string) ([]DNSRecord, error) {
+// newZoneConfig returns a zone configuration with its metadata initialised.
+func newZoneConfig() *ZoneConfig {
+	zoneConfig := &ZoneConfig{}
+	zoneConfig.Metadata.Labels = make(map[string]string)
+	zoneConfig.Metadata.Annotations = make(map[string]string)
+	zoneConfig.Metadata.Description = "Zone Converted from BIND Zone File by MC Tool"
+	return zoneConfig
+}
 
-	includedRecords, _, err := ParseZoneFile(filePath, includeOrigin, true, rootPath)
+// processZoneBlock reads a named.conf style zone statement found inside a
+// zone file and returns the zone name and its file.
+func processZoneBlock(zoneLines []string) (string, string, error) {
+	conf, err := parseNamedConfText(strings.Join(zoneLines, "\n"), "zone block")
 	if err != nil {
-		return nil, fmt.Errorf(ColorRed+"Error processing $INCLUDE %s: %v\n"+ColorReset, filePath, err)
+		return "", "", err
+	}
+	if len(conf.Zones) == 0 {
+		return "", "", fmt.Errorf("no zone statement found")
 	}
 
-	return includedRecords, nil
+	return conf.Zones[0].Name, conf.Zones[0].File, nil
 }
 
-func processIncludedZoneFile(zoneFilePath, outputFileName string, customOrigin string) {
+func processIncludedZoneFile(zoneFilePath, outputFileName string, customOrigin string, opts conversionOptions) {
 
 	// Parse the zone file
-	_, zoneConfig, err := ParseZoneFile(zoneFilePath, customOrigin, false, "")
+	zoneConfig, err := ParseZoneFile(zoneFilePath, customOrigin, "", opts)
 	if err != nil {
 		fmt.Printf("Error parsing zone file: %v\n", err)
 		return
 	}
 
-	// Marshal the zone configuration to JSON
-	jsonBytes, err := json.MarshalIndent(zoneConfig, "", "  ")
-	if err != nil {
-		fmt.Printf("Error marshaling to JSON: %v\n", err)
-		return
-	}
-
-	// Write the JSON output to the specified file
-	err = os.WriteFile(outputFileName, jsonBytes, 0644)
-	if err != nil {
-		fmt.Printf("Error writing to output file: %v\n", err)
+	if err := writeZoneConfig(zoneConfig, filepath.Join(opts.OutputDir, outputFileName), opts); err != nil {
+		fmt.Printf("Error: %v\n", err)
 		return
 	}
 }
 
-func sanitizeHostname(hostname, origin string) string {
-	// Convert the hostname to lowercase
-	hostname = strings.ToLower(hostname)
-
-	// Remove any instances of ".."
-	hostname = strings.ReplaceAll(hostname, "..", ".")
-
-	// Ensure the origin is not duplicated at the end of the hostname
-	dotOrigin := "." + strings.TrimPrefix(origin, ".")
-	if strings.HasSuffix(hostname, dotOrigin+dotOrigin) {
-		hostname = strings.TrimSuffix(hostname, dotOrigin)
-	}
-
-	// Allow alphanumeric, hyphens, periods, and underscore
-	re := regexp.MustCompile(`[^a-zA-Z0-9\-\._]+`)
-	sanitized := re.ReplaceAllString(hostname, "")
-
-	// Ensure it does not start or end with a hyphen or period (common DNS rule)
-	re = regexp.MustCompile(`(^[-\.]+|[-\.]+$)`)
-	sanitized = re.ReplaceAllString(sanitized, "")
-
-	// Pattern allows hostnames according to specified rules
-	pattern := `^([*]|[a-zA-Z0-9-/_]{1,63})([.][a-zA-Z0-9-/_]{1,63})*$`
-	re2 := regexp.MustCompile(pattern)
-
-	if !re2.MatchString(sanitized) {
-		fmt.Printf("%sWarning:%s Hostname may not meet requirement for XC DNS: %s %s\n", ColorRed, ColorYellow, sanitized, ColorReset)
-	}
-
-	// Remove any trailing dot to ensure a clean hostname as the final step
-	sanitized = strings.TrimSuffix(sanitized, ".")
-
-	return sanitized
-}
-
-func removeConflictingCNAMEs(records []DNSRecord, origin string, includeOrigin string) ([]DNSRecord, error) {
-	aRecordHostnames := make(map[string]bool)
-	filteredRecords := make([]DNSRecord, 0)
-
-	// First, collect all A record hostnames
-	for _, record := range records {
-		if record.ARecord != nil {
-			aRecordHostnames[record.ARecord.Name] = true
-		}
-	}
-
-	// Now, filter out CNAME records that conflict with A record hostnames
-	for _, record := range records {
-		if record.CNAMERecord != nil {
-			if _, exists := aRecordHostnames[record.CNAMERecord.Name]; exists {
-				// Log an error and skip adding this CNAME record
-				//fmt.Printf(ColorRed+"Error:"+ColorYellow+" CNAME record for hostname '%s at origin %s' removed due to conflict with an A record: %s\n"+ColorReset, record.CNAMERecord.Name, includeOrigin, record.CNAMERecord.Name)
-				//fmt.Printf("CNAME record name cannot be shared with other record types: A\n")
-				continue
-			}
-		}
-		// Add non-conflicting records to the filtered list
-		filteredRecords = append(filteredRecords, record)
-	}
-
-	return filteredRecords, nil
+// rrSetTypeAndName returns the record type and owner name of an RR set.
+func rrSetTypeAndName(record DNSRecord) (string, string) {
+	switch {
+	case record.ARecord != nil:
+		return "A", record.ARecord.Name
+	case record.AAAARecord != nil:
+		return "AAAA", record.AAAARecord.Name
+	case record.NSRecord != nil:
+		return "NS", record.NSRecord.Name
+	case record.CNAMERecord != nil:
+		return "CNAME", record.CNAMERecord.Name
+	case record.MXRecord != nil:
+		return "MX", record.MXRecord.Name
+	case record.TXTRecord != nil:
+		return "TXT", record.TXTRecord.Name
+	case record.SRVRecord != nil:
+		return "SRV", record.SRVRecord.Name
+	case record.CAARecord != nil:
+		return "CAA", record.CAARecord.Name
+	case record.PTRRecord != nil:
+		return "PTR", record.PTRRecord.Name
+	case record.NAPTRRecord != nil:
+		return "NAPTR", record.NAPTRRecord.Name
+	case record.SSHFPRecord != nil:
+		return "SSHFP", record.SSHFPRecord.Name
+	case record.TLSARecord != nil:
+		return "TLSA", record.TLSARecord.Name
+	case record.DSRecord != nil:
+		return "DS", record.DSRecord.Name
+	}
+	return "", ""
 }
 
 func sanitizeValue(value string) string {
@@ -484,10 +355,27 @@ func sanitizeValue(value string) string {
 	return sanitized
 }
 
+// qualifyName makes a domain name from record data absolute against origin and
+// returns it without the trailing dot. "@" is the origin itself and the root
+// name "." comes back as "".
+func qualifyName(name, origin string) string {
+	origin = strings.TrimSuffix(origin, ".")
+	switch {
+	case name == "@":
+		return origin
+	case strings.HasSuffix(name, "."):
+		return strings.TrimSuffix(name, ".")
+	case origin == "":
+		return name
+	}
+	return name + "." + origin
+}
+
 // ensureFQDN checks if a given value is a proper FQDN. If not, it appends the origin.
 func ensureFQDN(value, origin string) (string, bool) {
 	// Simple pattern to match basic FQDN structure, without advanced assertions
-	fqdnPattern := regexp.MustCompile(`^(?:[a-zA-Z0-9-_]{1,63}\.)+[a-zA-Z]{2,}$`)
+	// '/' is allowed in labels for RFC 2317 classless reverse delegations
+	fqdnPattern := regexp.MustCompile(`^(?:[a-zA-Z0-9-_/]{1,63}\.)+[a-zA-Z]{2,}$`)
 
 	value = strings.TrimSuffix(value, ".") // Ensure no trailing dot for the validation
 
@@ -508,22 +396,6 @@ func ensureFQDN(value, origin string) (string, bool) {
 	return value, isFQDN
 }
 
-func isHostnameValid(hostname string) bool {
-	// Check if hostname ends with a trailing period
-	if strings.HasSuffix(hostname, ".") {
-		return false
-	}
-
-	// Check if the hostname is not all lowercase (indicating mixed case)
-	if hostname != strings.ToLower(hostname) {
-		return false
-	}
-
-	pattern := `^[a-zA-Z0-9]([a-zA-Z0-9\-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9\-]{0,61}[a-zA-Z0-9])?)*$`
-	matched, _ := regexp.MatchString(pattern, hostname)
-	return matched
-}
-
 func consolidateTXTRecords(records []DNSRecord) ([]DNSRecord, error) {
 	// Initialize a map to hold consolidated TXT records by name
 	consolidatedRecordsMap := make(map[string]*DNSRecord)
@@ -536,17 +408,9 @@ func consolidateTXTRecords(records []DNSRecord) ([]DNSRecord, error) {
 			// Handle TXT records without a hostname
 			key := "TXT-no-hostname"
 			if existingRecord, exists := consolidatedRecordsMap[key]; exists {
-				// Check if appending this record would exceed the limit
-				if len(existingRecord.TXTRecord.Values)+len(record.TXTRecord.Values) > 100 {
-					// Log an error with all values that won't be included
-					excessValues := record.TXTRecord.Values[100-len(existingRecord.TXTRecord.Values):]
-					fmt.Printf(ColorRed+"Error: Exceeded TXT record values limit for records without a hostname. Excess values: %v%s\n", excessValues, ColorReset)
-					// Only append values up to the limit
-					existingRecord.TXTRecord.Values = append(existingRecord.TXTRecord.Values, record.TXTRecord.Values[:100-len(existingRecord.TXTRecord.Values)]...)
-				} else {
-					// Append values to the existing TXT record
-					existingRecord.TXTRecord.Values = append(existingRecord.TXTRecord.Values, record.TXTRecord.Values...)
-				}
+				// Append values to the existing TXT record, the 100 value limit is
+				// enforced by enforceXCLimits
+				existingRecord.TXTRecord.Values = append(existingRecord.TXTRecord.Values, record.TXTRecord.Values...)
 			} else {
 				// If it's the first record of its kind, add it to the map
 				newRecord := record // Make a copy to avoid modifying the original
@@ -566,53 +430,52 @@ func consolidateTXTRecords(records []DNSRecord) ([]DNSRecord, error) {
 	return finalRecords, nil
 }
 
-func ParseZoneFile(filePath string, customOrigin string, onlyRecords bool, bindFileRootPath string) ([]DNSRecord, *ZoneConfig, error) {
+func ParseZoneFile(filePath string, customOrigin string, bindFileRootPath string, opts conversionOptions) (*ZoneConfig, error) {
 
-	fileDir := filepath.Clean(bindFileRootPath)
+	if opts.Zone.processedFiles[filePath] {
+		fmt.Printf("Skipping already processed file: %s\n", filePath)
+		return nil, fmt.Errorf("file already processed: %s", filePath)
+	}
+	opts.Zone.processedFiles[filePath] = true
 
-	//fmt.Println("Using root zone file directory:", fileDir)
+	customOrigin = resolveOrigin(customOrigin)
 
-	if !onlyRecords {
-		if processedFiles[filePath] {
-			fmt.Printf("Skipping already processed file: %s\n", filePath)
-			return nil, nil, fmt.Errorf("file already processed: %s", filePath)
-		}
-		processedFiles[filePath] = true
+	// Tokenize the file (and any $INCLUDEs) into a typed record stream first
+	reader := newZoneReader(customOrigin, bindFileRootPath, defaultTTLValue, opts)
+	if err := reader.readFile(filePath); err != nil {
+		return nil, err
 	}
 
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to open file: %v", err)
-	}
-	defer file.Close()
+	return convertZoneRecords(reader, customOrigin, opts)
+}
 
-	scanner := bufio.NewScanner(file)
+// resolveOrigin allows a network to be given as the origin of a reverse zone,
+// e.g. 192.0.2.0/24.
+func resolveOrigin(customOrigin string) string {
+	if strings.Contains(customOrigin, "/") {
+		if reverseZone, err := reverseZoneFromCIDR(customOrigin); err == nil {
+			return reverseZone
+		}
+	}
+	return customOrigin
+}
 
-	const defaultTTLValue = 300          // Define a constant for the default TTL
-	var defaultTTL int = defaultTTLValue // Use this variable to store the effective default TTL
+// convertZoneRecords turns the records gathered by a zone reader, whether read
+// from files or a zone transfer, into XC RR sets.
+func convertZoneRecords(reader *zoneReader, customOrigin string, opts conversionOptions) (*ZoneConfig, error) {
+	origin := reader.apex
+	defaultTTL := reader.defaultTTL // The effective default TTL after any $TTL directives
 
-	var lastTTL int
-	var origin string
-	var originalOrigin string
-	var includeOrigin string
-	var lastHostname string = "@" // Assume root by default for records without an explicit hostname
-	//var lastRecordType string     // Flag for null hostnames to join into array
-	var inSOARecord bool  // Flag to indicate if we're currently processing an SOA record
-	var soaLines []string // Temporarily store SOA record lines for processing
 	var records []DNSRecord
-	var inZoneBlock bool // Flag to indicate we're currently processing a zone block for includes
-	var zoneConfigLines []string
+	var err error
 
 	var aDescription string = "" // include description for A records
 
 	var zoneConfig *ZoneConfig
 
 	if zoneConfig == nil {
-		zoneConfig = &ZoneConfig{}
-		zoneConfig.Metadata.Labels = make(map[string]string)
-		zoneConfig.Metadata.Annotations = make(map[string]string)
-		zoneConfig.Metadata.Description = "Zone Converted from BIND Zone File by MC Tool"
-
+		zoneConfig = newZoneConfig()
+		zoneConfig.Spec.Primary = &PrimaryZone{}
 	}
 
 	// Outside the parsing loop, prepare to collect NS / A records
@@ -634,233 +497,60 @@ func ParseZoneFile(filePath string, customOrigin string, onlyRecords bool, bindF
 
 	cnameRecordsMap := make(map[string]*CNAMERecord)
 
-	// Initialize with user-provided customOrigin if available
-	if customOrigin != "" {
-		origin = customOrigin
-		originalOrigin = customOrigin // Treat the customOrigin as the original if provided
-	}
-
-	for scanner.Scan() {
-		line := scanner.Text() // Use the original line with leading spaces for whitespace detection
-		trimmedLine := strings.TrimSpace(line)
-
-		// Skip comments
-		if trimmedLine == "" || strings.HasPrefix(trimmedLine, ";") {
-			continue
-		}
-
-		if !onlyRecords {
-
-			// Handle $ORIGIN directive within the file only if customOrigin is not provided
-			if strings.HasPrefix(trimmedLine, "$ORIGIN") {
-				foundOrigin := strings.Fields(trimmedLine)[1]
-				if origin == "" || origin == customOrigin { // Update origin only if not set by user
-					origin = foundOrigin
-					if originalOrigin == customOrigin { // Only update originalOrigin if not overridden by user
-						originalOrigin = foundOrigin
-					}
-				}
-				zoneConfig.Metadata.Name = origin
-				continue
-			}
-
-			// Use customOrigin as the default if no $ORIGIN directive was found in the file
-			if origin == "" {
-				// Since customOrigin is also "", no origin has been specified or detected
-				return nil, nil, fmt.Errorf("no $ORIGIN specified and none detected in the file")
-			}
-
-			// Special handling for $TTL
-			if strings.HasPrefix(trimmedLine, "$TTL") {
-				fields := strings.Fields(trimmedLine)
-				if len(fields) > 1 {
-					ttlValue, err := parseTTL(fields[1])
-					if err != nil {
-						fmt.Printf("Error parsing TTL value '%s': %v. Using default TTL: %d\n", fields[1], err, defaultTTLValue)
-						defaultTTL = defaultTTLValue // Use the default TTL if parsing fails
-					} else {
-						defaultTTL = ttlValue // Update the default TTL with the parsed value
-					}
-				}
-				continue
-			}
-
-			if inSOARecord || strings.Contains(trimmedLine, "SOA") {
-				soaLines = append(soaLines, trimmedLine)
-				// Check if this is the last line of the SOA record
-				if strings.Contains(line, ")") {
-					inSOARecord = false // We've reached the end of the SOA record
-					processSOA(soaLines, &zoneConfig.Spec.Primary.SOAParameters)
-					soaLines = []string{} // Reset for safety
-				} else {
-					inSOARecord = true // Continue collecting SOA lines
-				}
-				continue
-			}
-
-		}
-
-		// Handle the start and end of a zone block
-		if !inZoneBlock && strings.HasPrefix(trimmedLine, "zone \"") {
-			inZoneBlock = true
-			zoneConfigLines = []string{trimmedLine} // Start a new zone config block
-		} else if inZoneBlock {
-			zoneConfigLines = append(zoneConfigLines, trimmedLine)
-			if strings.HasSuffix(trimmedLine, "};") {
-				inZoneBlock = false // End of zone config block
-				domainName, zoneFilePath, err := processZoneBlock(zoneConfigLines)
-				if err != nil {
-					fmt.Printf("Error processing zone block: %v\n", err)
-					continue
-				}
-				// Now domainName can be used for the output filename
-				if domainName != "" && zoneFilePath != "" {
-					fmt.Printf("Processing %s from %s\n", domainName, zoneFilePath)
-
-					processIncludedZoneFile(zoneFilePath, domainName+".json", origin)
-				}
-			}
-		}
+	caaRecordsMap := make(map[string]*CAARecord) // For accumulating CAA record values by hostname
 
-		var ttl int
-		var err error
-		var hostname, recordType string
-		var values []string
-		var recordValueStartIndex int = -1
+	mxRecordsMap := make(map[string]*MXRecord) // For accumulating MX record values by hostname
 
-		_ = values
+	ptrRecordsMap := make(map[string]*PTRRecord) // For accumulating PTR record values by hostname
 
-		// Main parsing logic for other record types
-		parts := strings.Fields(trimmedLine)
+	// For accumulating NAPTR, SSHFP, TLSA and DS record values by hostname
+	naptrRecordsMap := make(map[string]*NAPTRRecord)
+	sshfpRecordsMap := make(map[string]*SSHFPRecord)
+	tlsaRecordsMap := make(map[string]*TLSARecord)
+	dsRecordsMap := make(map[string]*DSRecord)
+	seenRData := make(map[string]bool) // type, hostname and rdata of values already added to the maps above
 
-		// Detect whether the line starts with whitespace indicating continuation of previous record
-		startsWithWhitespace := strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
-
-		if startsWithWhitespace {
-			if isInt(parts[0]) && !inZoneBlock {
-				// This is a TTL at the start of a continuation line
-				ttl, err = strconv.Atoi(parts[0])
-				if err != nil || ttl <= 0 {
-					ttl = defaultTTL
-				}
-				recordType = parts[1]
-				values = parts[2:]
-			} else {
-				// Continuation line without a leading TTL, use last known values
-				hostname = lastHostname
-				ttl = lastTTL
-				recordType = parts[0]
-				values = parts[1:]
-			}
-		} else {
-			if isInt(parts[0]) && !inZoneBlock {
-				// Line starts with TTL
-				ttl, err = strconv.Atoi(parts[0])
-				if err != nil || ttl <= 0 {
-					ttl = defaultTTL
-				}
-				recordType = parts[1]
-				values = parts[2:]
-			} else if !inZoneBlock && len(parts) > 2 {
-				// Line starts with hostname or "IN"
-				if parts[0] != "IN" {
-					hostname = parts[0]
-					ttl, err = strconv.Atoi(parts[1])
-					if err != nil || ttl <= 0 {
-						ttl = defaultTTL
-					}
-				} else {
-					// "IN" indicates the record class, not a hostname; use the last known hostname
-					hostname = lastHostname
-					ttl, err = strconv.Atoi(parts[0])
-					if err != nil || ttl <= 0 {
-						ttl = defaultTTL
-					}
-				}
-				recordType = parts[2]
-				values = parts[3:]
-			}
-			lastHostname = hostname // Update the last known hostname
-
-			// you cant trick me with your zero value!
-			if ttl <= 0 {
-				lastTTL = defaultTTL // Set to default TTL if ttl is zero or negative
-			} else {
-				lastTTL = ttl // Update the last known TTL if it's a positive value
-			}
+	// Each RR set keeps the TTL written in the zone file, see -ttl-policy
+	rrTTLs := newRRSetTTLs(opts.TTLPolicy)
 
+	for _, record := range reader.records {
+		if record.Class != "IN" {
+			opts.Zone.skipRecord(record, fmt.Sprintf("only class IN records can be converted, found class %s", record.Class))
+			continue
 		}
 
-		for i, part := range parts {
-			if strings.Contains(" NS MX A AAAA TXT CNAME SRV ", " "+part+" ") {
-				recordType = part
-				recordValueStartIndex = i + 1
-				if i > 0 && isInt(parts[0]) {
-					ttl, _ = strconv.Atoi(parts[0])
-					lastTTL = ttl
-				} else {
-					ttl = lastTTL
-				}
-				if i == 2 { // Hostname is present
-					hostname = strings.Replace(parts[0], " ", "", -1)
-				}
-				break
-			}
-		}
+		// Hostname relative to the zone apex, "" for the apex itself
+		hostname := record.Name
+		values := record.rdataValues()
 
-		// Adjust hostname based on includeOrigin if processing records only
-		if onlyRecords && includeOrigin != "" {
-			if hostname == "@" || hostname == "" {
-				hostname = includeOrigin
-			} else {
-				hostname = hostname + "." + includeOrigin
+		switch record.Type {
+		case "SOA":
+			if err := processSOA(values, record.TTL, &zoneConfig.Spec.Primary.SOAParameters); err != nil {
+				opts.Zone.skipRecord(record, err.Error())
 			}
-		}
-
-		var dnsRecord DNSRecord
-
-		switch recordType {
+			continue
 		case "A":
-			isRoot := hostname == "@" || hostname == "" || hostname == "IN"
-
-			if !isRoot && hostname == "" {
-				hostname = lastHostname // Use the last known valid hostname
-			} else {
-				if hostname == "" {
-					hostname = "@"
-				}
+			if len(values) < 1 {
+				opts.Zone.skipRecord(record, "missing address")
+				continue
 			}
+			isRoot := hostname == ""
 
-			// Split the value to sanitize it and possibly capture the description
-			valueParts := strings.SplitN(parts[recordValueStartIndex], ";", 2)
-			sanitizedValue := strings.TrimSpace(valueParts[0]) // The actual A record value, sanitized
-			if len(valueParts) > 1 {
-				description := strings.TrimSpace(valueParts[1]) // Capture the description part
-				aDescription = description
+			if record.Comment != "" {
+				aDescription = record.Comment // Capture the description part
 			}
 
 			if isRoot {
-				// For root-level records, append sanitizedValue directly
-				rootARecords = append(rootARecords, sanitizedValue)
+				// For root-level records, append the value directly
+				rootARecords = append(rootARecords, values[0])
 			} else {
-				// For subdomain records, use lastValidHostname if hostname is not explicitly set
-				subdomainARecords[hostname] = append(subdomainARecords[hostname], sanitizedValue)
+				subdomainARecords[hostname] = append(subdomainARecords[hostname], values[0])
 			}
 		case "NS":
-			if len(parts) > recordValueStartIndex {
-				nsValue := parts[recordValueStartIndex]
-				var root bool
+			if len(values) > 0 {
+				nsValue := qualifyName(values[0], record.Origin)
 
-				if parts[0] == "@" || parts[0] == "" || isInt(parts[0]) || parts[0] == "IN" {
-					root = true
-				} else {
-					hostname = parts[0]
-					root = false
-				}
-
-				nsValue = sanitizeValue(nsValue)
-
-				if root {
+				if hostname == "" {
 					// Check if value is already in the set for root NS records
 					if _, exists := rootNSSet[nsValue]; !exists {
 						rootNSSet[nsValue] = struct{}{}
@@ -877,27 +567,34 @@ func ParseZoneFile(filePath string, customOrigin string, onlyRecords bool, bindF
 						subdomainNSRecords[hostname] = append(subdomainNSRecords[hostname], nsValue) // Append only if not exists
 					}
 				}
+			} else {
+				opts.Zone.skipRecord(record, "missing name server")
+				continue
 			}
 		case "CNAME":
-			err := processCNAME(line, cnameRecordsMap, origin, customOrigin)
+			if len(values) < 1 {
+				opts.Zone.skipRecord(record, "invalid CNAME record format: missing value")
+				continue
+			}
+			err := processCNAME(hostname, values[0], record.Origin, cnameRecordsMap, origin, customOrigin)
 			if err != nil {
-				fmt.Println("Error processing CNAME:", err)
+				opts.Zone.skipRecord(record, err.Error())
+				continue
 			}
 		case "SRV":
-			if len(parts) >= 6 {
-				priority, errPri := strconv.Atoi(parts[3])
-				weight, errWei := strconv.Atoi(parts[4])
-				port, errPort := strconv.Atoi(parts[5])
-				target := parts[6] // Ensure this part exists or adapt accordingly
+			if len(values) >= 4 {
+				priority, errPri := strconv.Atoi(values[0])
+				weight, errWei := strconv.Atoi(values[1])
+				port, errPort := strconv.Atoi(values[2])
+				target := values[3]
 
 				if errPri != nil || errWei != nil || errPort != nil {
-					//fmt.Println("Error parsing SRV record parts: <priority> <weight> <port> <target>", errPri, errWei, errPort, target)
-					fmt.Printf(ColorRed+"Error Parsing SRV record - Priority: %d, Weight: %d, Port: %d, Target: %s\n"+ColorReset, priority, weight, port, target)
+					opts.Zone.skipRecord(record, fmt.Sprintf("invalid SRV record - Priority: %s, Weight: %s, Port: %s, Target: %s", values[0], values[1], values[2], target))
 
 					continue // Skip this record on parsing error
 				}
 
-				target = sanitizeValue(target)
+				target = qualifyName(target, record.Origin)
 
 				srvValue := struct {
 					Priority int    `json:"priority"`
@@ -931,146 +628,217 @@ func ParseZoneFile(filePath string, customOrigin string, onlyRecords bool, bindF
 					}
 				}
 			} else {
-				partsAsString := strings.Join(parts, " ")
-				fmt.Println("Insufficient parts to parse SRV record. Parsed values:", partsAsString)
-
+				opts.Zone.skipRecord(record, "invalid SRV record format: expected <priority> <weight> <port> <target>")
+				continue
 			}
-		// case "CAA":
-		// 	// Parse CAA record
-		// 	if len(parts) > recordValueStartIndex {
-		// 		hostname := parts[0] // Assuming the first part is always the hostname
-		// 		value := parts[recordValueStartIndex]
-		// 		dnsRecord := DNSRecord{
-		// 			TTL:         ttl,
-		// 			CNAMERecord: &CNAMERecord{Name: hostname, Value: value},
-		// 		}
-		// 		if isValidDNSRecord(dnsRecord) {
-		// 			records = append(records, dnsRecord)
-		// 		}
-		// 	}
-		case "TXT":
-			// Parse TXT record
-			if len(parts) > recordValueStartIndex {
-				// First, extract the entire quoted string for the TXT value, including semicolons within quotes
-				quotedValueRegex := regexp.MustCompile(`"([^"]+)"`)
-				matches := quotedValueRegex.FindAllStringSubmatch(line, -1)
-				var recordValues []string
-				var description string
-
-				for _, match := range matches {
-					if len(match) > 1 {
-						// Append each quoted string (without quotes) to recordValues
-						recordValues = append(recordValues, match[1])
+		case "CAA":
+			caaValue, err := processCAA(values)
+			if err != nil {
+				opts.Zone.skipRecord(record, err.Error())
+				continue
+			}
+
+			if existingRecord, exists := caaRecordsMap[hostname]; exists {
+				duplicate := false
+				for _, value := range existingRecord.Values {
+					if value == caaValue {
+						duplicate = true
+						break
 					}
 				}
-
-				// Concatenate all parts of the TXT record separated by a space
-				recordValue := strings.Join(recordValues, " ")
-
-				// Check the length of the concatenated recordValue
-				if len(recordValue) >= 512 {
-					fmt.Printf(ColorRed+"Warning: "+ColorYellow+"TXT record [%s] value too long (%d) and will not be included: %s%s\n", hostname, len(recordValue), recordValue, ColorReset)
-					continue // Skip adding this record
-				} else if len(recordValue) <= 0 {
-					fmt.Printf(ColorRed+"Warning: "+ColorYellow+"TXT record [%s] value is null (%d) and will not be included: %s%s\n", hostname, len(recordValue), recordValue, ColorReset)
-					continue
+				if !duplicate {
+					existingRecord.Values = append(existingRecord.Values, caaValue)
 				}
-
-				// Generate a key for each TXT record based on hostname and record value
-				txtKey := fmt.Sprintf("%s-%s", hostname, recordValue)
-
-				// Now, separate the description if any, which should be after a semicolon that's outside of quotes
-				if lastQuoteEnd := quotedValueRegex.FindStringIndex(line); lastQuoteEnd != nil && len(line) > lastQuoteEnd[1] {
-					// Find the first semicolon after the quoted TXT value
-					descriptionStartIndex := strings.Index(line[lastQuoteEnd[1]:], ";")
-					if descriptionStartIndex != -1 {
-						// Adjust index relative to the entire line and not just the substring
-						descriptionStartIndex += lastQuoteEnd[1]
-						// Extract description, trim leading/trailing spaces
-						description = strings.TrimSpace(line[descriptionStartIndex+1:])
-					}
+			} else {
+				caaRecordsMap[hostname] = &CAARecord{
+					Name:   hostname,
+					Values: []CAAValue{caaValue},
 				}
-
-				// Determine hostname and set to "" if specific conditions are met
-				if len(parts) >= 3 && (parts[0] == "" || isInt(parts[0]) || parts[0] == "IN") && parts[1] == "TXT" {
-					hostname = ""
+			}
+		case "TXT":
+			// Each character-string of the record, quoted or not, has already been unescaped by the lexer
+			var recordValues []string
+			for _, value := range values {
+				if value != "" {
+					recordValues = append(recordValues, value)
 				}
+			}
 
-				// Check if this TXT record is already in the map
-				if _, exists := txtRecordsMap[txtKey]; !exists {
-					// If not, add it to the map
-					txtRecordsMap[txtKey] = &TXTRecordWithDesc{
-						TXTRecord: &TXTRecord{
-							Name:   hostname,
-							Values: []string{recordValue},
-						},
-						Description: description,
-					}
-				}
+			// A record made of several character-strings is one value to the consumer,
+			// long DKIM keys are split into 255 byte chunks that must be joined back as-is
+			recordValue := strings.Join(recordValues, "")
 
+			// Length limits are checked by enforceXCLimits
+			if len(recordValue) <= 0 {
+				opts.Zone.skipRecord(record, "TXT value is empty")
+				continue
+			}
+
+			// Generate a key for each TXT record based on hostname and record value
+			txtKey := fmt.Sprintf("%s-%s", hostname, recordValue)
+
+			// Check if this TXT record is already in the map
+			if _, exists := txtRecordsMap[txtKey]; !exists {
+				// If not, add it to the map
+				txtRecordsMap[txtKey] = &TXTRecordWithDesc{
+					TXTRecord: &TXTRecord{
+						Name:   hostname,
+						Values: []string{recordValue},
+					},
+					Description: record.Comment,
+				}
 			}
 		case "MX":
-			if len(parts) > recordValueStartIndex+1 {
-				priority, err := strconv.Atoi(parts[recordValueStartIndex])
+			if len(values) > 1 {
+				priority, err := strconv.Atoi(values[0])
 				if err != nil {
-					fmt.Println("Error parsing MX record priority:", parts[recordValueStartIndex])
+					opts.Zone.skipRecord(record, fmt.Sprintf("invalid MX priority %q", values[0]))
 					continue
 				}
-				mailServer := parts[recordValueStartIndex+1]
-				dnsRecord.MXRecord = &[]MXValue{{Priority: priority, Value: mailServer}}
-			}
-		case "AAAA":
-			if len(parts) > recordValueStartIndex {
-				var root bool
-
-				root = true
-
-				if parts[0] == "@" || parts[0] == "" || isInt(parts[0]) {
-					hostname = "@"
-					root = true
-				} else {
-					hostname = parts[0]
-					root = false
+				if priority < 0 || priority > 65535 {
+					opts.Zone.skipRecord(record, fmt.Sprintf("invalid MX priority %q", values[0]))
+					continue
 				}
 
-				if root {
-					rootAAAARecords = append(rootAAAARecords, parts[recordValueStartIndex])
+				// Mail server targets are relative to the $ORIGIN the record was written under
+				mailServer := qualifyName(values[1], record.Origin)
+				if mailServer == "" {
+					opts.Zone.skipRecord(record, "null MX records are not supported by XC DNS")
+					continue
+				}
+				mxValue := MXValue{Priority: priority, Value: mailServer}
+
+				if existingRecord, exists := mxRecordsMap[hostname]; exists {
+					duplicate := false
+					for _, value := range existingRecord.Values {
+						if value == mxValue {
+							duplicate = true
+							break
+						}
+					}
+					if !duplicate {
+						existingRecord.Values = append(existingRecord.Values, mxValue)
+					}
 				} else {
-					subdomainAAAARecords[hostname] = append(subdomainAAAARecords[hostname], parts[recordValueStartIndex])
+					mxRecordsMap[hostname] = &MXRecord{
+						Name:   hostname,
+						Values: []MXValue{mxValue},
+					}
 				}
+			} else {
+				opts.Zone.skipRecord(record, "invalid MX record format: expected <priority> <mail server>")
+				continue
+			}
+		case "PTR":
+			if len(values) < 1 {
+				opts.Zone.skipRecord(record, "invalid PTR record format: missing target")
+				continue
 			}
-		}
 
-		if strings.HasPrefix(trimmedLine, "$INCLUDE") {
-			parts := strings.Fields(trimmedLine)
-			if len(parts) >= 2 {
-				includeFilePath := filepath.Join(fileDir, parts[1]) // Construct the full path of the included file
-				includeOrigin = origin                              // Default to using the current origin if not specified in $INCLUDE
+			// PTR targets are relative to the $ORIGIN the record was written under
+			target := qualifyName(values[0], record.Origin)
+			if target == "" {
+				opts.Zone.skipRecord(record, "PTR record has no target")
+				continue
+			}
+			if !isReverseZone(origin) {
+				opts.Zone.skipRecord(record, "PTR records are only converted in reverse (in-addr.arpa or ip6.arpa) zones")
+				continue
+			}
 
-				if len(parts) >= 3 {
-					includeOrigin = parts[2] + "." + includeOrigin // Override with specific origin if provided
-					//fmt.Printf("Trying to import Include: %s with Origin: %s\n", parts[1], includeOrigin)
-					//fmt.Printf("Should we join %s to %s for %s\n", includeOrigin, origin, parts[1])
+			if existingRecord, exists := ptrRecordsMap[hostname]; exists {
+				if !contains(existingRecord.Values, target) {
+					existingRecord.Values = append(existingRecord.Values, target)
 				}
-
-				includedRecords, err := processIncludeDirective(includeFilePath, includeOrigin, bindFileRootPath)
-				if err != nil {
-					fmt.Printf(ColorRed+"Error processing $INCLUDE %s %s: %v\n"+ColorReset, line, includeFilePath, err)
+			} else {
+				ptrRecordsMap[hostname] = &PTRRecord{
+					Name:   hostname,
+					Values: []string{target},
 				}
-
-				records = append(records, includedRecords...)
+			}
+		case "NAPTR", "SSHFP", "TLSA", "DS":
+			rdataKey := record.Type + "|" + hostname + "|" + strings.Join(values, " ")
+			if seenRData[rdataKey] {
+				continue
 			}
 
+			var err error
+			switch record.Type {
+			case "NAPTR":
+				var naptrValue NAPTRValue
+				if naptrValue, err = processNAPTR(values, record.Origin); err == nil {
+					if existingRecord, exists := naptrRecordsMap[hostname]; exists {
+						existingRecord.Values = append(existingRecord.Values, naptrValue)
+					} else {
+						naptrRecordsMap[hostname] = &NAPTRRecord{Name: hostname, Values: []NAPTRValue{naptrValue}}
+					}
+				}
+			case "SSHFP":
+				var sshfpValue SSHFPValue
+				if sshfpValue, err = processSSHFP(values); err == nil {
+					if existingRecord, exists := sshfpRecordsMap[hostname]; exists {
+						existingRecord.Values = append(existingRecord.Values, sshfpValue)
+					} else {
+						sshfpRecordsMap[hostname] = &SSHFPRecord{Name: hostname, Values: []SSHFPValue{sshfpValue}}
+					}
+				}
+			case "TLSA":
+				var tlsaValue TLSAValue
+				if tlsaValue, err = processTLSA(values); err == nil {
+					if existingRecord, exists := tlsaRecordsMap[hostname]; exists {
+						existingRecord.Values = append(existingRecord.Values, tlsaValue)
+					} else {
+						tlsaRecordsMap[hostname] = &TLSARecord{Name: hostname, Values: []TLSAValue{tlsaValue}}
+					}
+				}
+			case "DS":
+				if hostname == "" {
+					err = fmt.Errorf("DS records belong at a delegation point, not the zone apex")
+					break
+				}
+				var dsValue DSValue
+				if dsValue, err = processDS(values); err == nil {
+					if existingRecord, exists := dsRecordsMap[hostname]; exists {
+						existingRecord.Values = append(existingRecord.Values, dsValue)
+					} else {
+						dsRecordsMap[hostname] = &DSRecord{Name: hostname, Values: []DSValue{dsValue}}
+					}
+				}
+			}
+			if err != nil {
+				opts.Zone.skipRecord(record, err.Error())
+				continue
+			}
+			seenRData[rdataKey] = true
+		case "AAAA":
+			if len(values) > 0 {
+				if hostname == "" {
+					rootAAAARecords = append(rootAAAARecords, values[0])
+				} else {
+					subdomainAAAARecords[hostname] = append(subdomainAAAARecords[hostname], values[0])
+				}
+			} else {
+				opts.Zone.skipRecord(record, "missing address")
+				continue
+			}
+		default:
+			opts.Zone.skipRecord(record, fmt.Sprintf("%s records are not supported by XC DNS", record.Type))
 			continue
 		}
 
+		// The record made it into a set, keep its TTL for that set
+		rrTTLs.add(record)
+	}
+
+	if err := rrTTLs.err(); err != nil {
+		return nil, err
 	}
 
 	// After parsing, create DNSRecord entries for the NS records
 	// I should actually just block Root Level NS since it will break...
 	if len(rootNSRecords) > 0 {
 		nsRecord := DNSRecord{
-			TTL:      86400, // Or determine TTL differently
+			TTL:      rrTTLs.get("NS", "", defaultTTL),
 			NSRecord: &NSRecord{Values: rootNSRecords},
 		}
 		records = append(records, nsRecord)
@@ -1078,7 +846,7 @@ func ParseZoneFile(filePath string, customOrigin string, onlyRecords bool, bindF
 
 	for subdomain, nsValues := range subdomainNSRecords {
 		nsRecord := DNSRecord{
-			TTL:      86400,
+			TTL:      rrTTLs.get("NS", subdomain, defaultTTL),
 			NSRecord: &NSRecord{Name: subdomain, Values: nsValues},
 		}
 
@@ -1087,7 +855,7 @@ func ParseZoneFile(filePath string, customOrigin string, onlyRecords bool, bindF
 
 	if len(rootARecords) > 0 {
 		aRecord := DNSRecord{
-			TTL:         86400, // Or determine TTL differently
+			TTL:         rrTTLs.get("A", "", defaultTTL),
 			ARecord:     &ARecord{Values: rootARecords},
 			Description: aDescription,
 		}
@@ -1097,7 +865,7 @@ func ParseZoneFile(filePath string, customOrigin string, onlyRecords bool, bindF
 	// After parsing, create DNSRecord entries for the A records similarly to NS records
 	for hostname, values := range subdomainARecords {
 		aRecord := DNSRecord{
-			TTL:         defaultTTL,
+			TTL:         rrTTLs.get("A", hostname, defaultTTL),
 			ARecord:     &ARecord{Name: hostname, Values: values},
 			Description: aDescription,
 		}
@@ -1106,7 +874,7 @@ func ParseZoneFile(filePath string, customOrigin string, onlyRecords bool, bindF
 
 	if len(rootAAAARecords) > 0 {
 		aaaaRecord := DNSRecord{
-			TTL:        defaultTTL,
+			TTL:        rrTTLs.get("AAAA", "", defaultTTL),
 			AAAARecord: &AAAARecord{Values: rootAAAARecords},
 		}
 		records = append(records, aaaaRecord)
@@ -1114,7 +882,7 @@ func ParseZoneFile(filePath string, customOrigin string, onlyRecords bool, bindF
 
 	for hostname, values := range subdomainAAAARecords {
 		aaaaRecord := DNSRecord{
-			TTL:        defaultTTL,
+			TTL:        rrTTLs.get("AAAA", hostname, defaultTTL),
 			AAAARecord: &AAAARecord{Name: hostname, Values: values},
 		}
 		records = append(records, aaaaRecord)
@@ -1122,7 +890,7 @@ func ParseZoneFile(filePath string, customOrigin string, onlyRecords bool, bindF
 
 	for _, srvRecord := range srvRecordsMap {
 		srvRecords := DNSRecord{
-			TTL:       defaultTTL,
+			TTL:       rrTTLs.get("SRV", srvRecord.Name, defaultTTL),
 			SRVRecord: srvRecord,
 		}
 
@@ -1132,7 +900,7 @@ func ParseZoneFile(filePath string, customOrigin string, onlyRecords bool, bindF
 	// Convert map entries back to DNSRecord and append them to records slice
 	for _, recordWithDesc := range txtRecordsMap {
 		txtRecord := DNSRecord{
-			TTL: defaultTTL,
+			TTL: rrTTLs.get("TXT", recordWithDesc.TXTRecord.Name, defaultTTL),
 			TXTRecord: &TXTRecord{
 				Name:   recordWithDesc.TXTRecord.Name,
 				Values: recordWithDesc.TXTRecord.Values,
@@ -1143,9 +911,43 @@ func ParseZoneFile(filePath string, customOrigin string, onlyRecords bool, bindF
 		records = append(records, txtRecord)
 	}
 
+	for _, mxRecord := range mxRecordsMap {
+		records = append(records, DNSRecord{
+			TTL:      rrTTLs.get("MX", mxRecord.Name, defaultTTL),
+			MXRecord: mxRecord,
+		})
+	}
+
+	for _, ptrRecord := range ptrRecordsMap {
+		records = append(records, DNSRecord{
+			TTL:       rrTTLs.get("PTR", ptrRecord.Name, defaultTTL),
+			PTRRecord: ptrRecord,
+		})
+	}
+
+	for _, naptrRecord := range naptrRecordsMap {
+		records = append(records, DNSRecord{TTL: rrTTLs.get("NAPTR", naptrRecord.Name, defaultTTL), NAPTRRecord: naptrRecord})
+	}
+	for _, sshfpRecord := range sshfpRecordsMap {
+		records = append(records, DNSRecord{TTL: rrTTLs.get("SSHFP", sshfpRecord.Name, defaultTTL), SSHFPRecord: sshfpRecord})
+	}
+	for _, tlsaRecord := range tlsaRecordsMap {
+		records = append(records, DNSRecord{TTL: rrTTLs.get("TLSA", tlsaRecord.Name, defaultTTL), TLSARecord: tlsaRecord})
+	}
+	for _, dsRecord := range dsRecordsMap {
+		records = append(records, DNSRecord{TTL: rrTTLs.get("DS", dsRecord.Name, defaultTTL), DSRecord: dsRecord})
+	}
+
+	for _, caaRecord := range caaRecordsMap {
+		records = append(records, DNSRecord{
+			TTL:       rrTTLs.get("CAA", caaRecord.Name, defaultTTL),
+			CAARecord: caaRecord,
+		})
+	}
+
 	for _, cnameRecords := range cnameRecordsMap {
 		cnameRecord := DNSRecord{
-			TTL: defaultTTL,
+			TTL: rrTTLs.get("CNAME", cnameRecords.Name, defaultTTL),
 			CNAMERecord: &CNAMERecord{
 				Name:  cnameRecords.Name,
 				Value: cnameRecords.Value,
@@ -1154,12 +956,6 @@ func ParseZoneFile(filePath string, customOrigin string, onlyRecords bool, bindF
 		records = append(records, cnameRecord)
 	}
 
-	// Remove conflicting CNAME records first
-	records, err = removeConflictingCNAMEs(records, customOrigin, includeOrigin)
-	if err != nil {
-		fmt.Printf("I hit a snag...")
-	}
-
 	// Remove complete duplicates
 	records = deduplicateAndMergeDNSRecords(records)
 
@@ -1171,10 +967,14 @@ func ParseZoneFile(filePath string, customOrigin string, onlyRecords bool, bindF
 		//return // or continue
 	}
 
+	// Same zone, same output
+	sortRRSets(records)
+
 	zoneConfig.Metadata.Name = origin
 	//zoneConfig.Spec.Primary.DefaultRRSetGroup = records
 	zoneConfig.Spec.Primary.DefaultRRSetGroup = records
 	zoneConfig.Spec.Primary.DNSSECMode = DNSSECMode{Disable: DisabledType{}}
+	groupRRSets(zoneConfig.Spec.Primary, reader.records, reader.mainFile, opts.GroupBy)
 
 	// Use 'origin' after ensuring it's captured
 	if origin != "" {
@@ -1183,53 +983,114 @@ func ParseZoneFile(filePath string, customOrigin string, onlyRecords bool, bindF
 		// Handle the case where $ORIGIN might not be present or needed
 		fmt.Println("Notice: $ORIGIN not specified, using a default or existing zoneConfig.Metadata.Name value.")
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, nil, err
-	}
 
-	// doing a final check on SOA values
-	if zoneConfig.Spec.Primary.SOAParameters.Refresh <= 3600 {
-		zoneConfig.Spec.Primary.SOAParameters.Refresh = 86400 // Set to a default refresh value
-	}
-	if zoneConfig.Spec.Primary.SOAParameters.Retry <= 7200 {
-		zoneConfig.Spec.Primary.SOAParameters.Retry = 7200 // Set to a default retry value
-	}
-	if zoneConfig.Spec.Primary.SOAParameters.Expire <= 3600000 {
-		zoneConfig.Spec.Primary.SOAParameters.Expire = 3600000 // Set to a default expire value
-	}
-	if zoneConfig.Spec.Primary.SOAParameters.NegativeTTL <= 1801 {
-		zoneConfig.Spec.Primary.SOAParameters.NegativeTTL = 1801 // Set to a default negative TTL value
-	}
-	if zoneConfig.Spec.Primary.SOAParameters.TTL <= 300 {
-		zoneConfig.Spec.Primary.SOAParameters.TTL = 300 // Set to a default TTL value
-	}
+	// One pass over the final zone for everything XC would reject, SOA
+	// timers included
+	enforceXCLimits(zoneConfig, reader.records, opts)
 
-	// Return based on the onlyRecords flag.
-	if onlyRecords {
-		return records, nil, nil // Return only records and no error.
-	} else {
-		return nil, zoneConfig, nil // Return the full ZoneConfig and no error.
-	}
+	return zoneConfig, nil
 }
 
 func main() {
 
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		os.Exit(runDiff(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && (os.Args[1] == "plan" || os.Args[1] == "apply") {
+		os.Exit(runPlan(os.Args[1], os.Args[2:]))
+	}
+
 	// Define command-line flags
 	inputFilePath := flag.String("input", "", "Path to the input zone file")
 	outputFilePath := flag.String("output", "", "Path to the output JSON file")
 	bindFileRootPath := flag.String("root", ".", "BIND file root path for resolving file references")
 	customOrigin := flag.String("origin", "", "Optional origin to override $ORIGIN in the zone file")
+	namedConfPath := flag.String("named-conf", "", "Path to a BIND named.conf; converts every zone it defines instead of a single -input file")
+	outputDir := flag.String("output-dir", ".", "Directory for the per-zone outputs and manifest.json in -named-conf mode")
+	reverse := flag.Bool("reverse", false, "Convert an XC DNS JSON -input back into a BIND zone file -output")
+	axfrServer := flag.String("axfr", "", "Read the zone named by -origin through a zone transfer from this server (host[:port]) instead of -input; in -named-conf mode primary zones are transferred")
+	tsig := flag.String("tsig", "", "TSIG key for -axfr as [algorithm:]name:secret, algorithm defaults to hmac-sha256")
+	views := flag.String("views", "", "Comma-separated list of named.conf views to convert (default all views)")
+	secretsFile := flag.String("secrets-file", "", "In -named-conf mode, leave TSIG secrets out of the zone outputs and write them to this file")
+	ttlPolicy := flag.String("ttl-policy", TTLPolicyMin, "TTL to use when records in the same RR set disagree: min, max or error")
+	skippedReportPath := flag.String("skipped-report", "", "Optional path to write the records that could not be converted as JSON")
+	push := flag.String("push", "", "Push converted zones to the XC API: dry-run, create or replace")
+	apiURL := flag.String("api-url", "", "XC API URL, e.g. https://tenant.console.ves.volterra.io/api (default $VOLT_API_URL)")
+	apiToken := flag.String("api-token", "", "XC API token (default $VOLTERRA_TOKEN)")
+	apiP12 := flag.String("api-p12", "", "XC API certificate as a .p12 file, its password is read from $VES_P12_PASSWORD (default $VOLT_API_P12_FILE)")
+	namespace := flag.String("namespace", "system", "XC namespace to push zones to, also written into -format yaml and terraform outputs")
+	groupBy := flag.String("group-by", "", "Split RR sets into named rr_set_groups: subdomain (per delegation), include (per $INCLUDE file) or tag (per group= comment)")
+	format := flag.String("format", FormatJSON, "Output format: json, yaml (for vesctl) or terraform (a volterra_dns_zone resource)")
 
 	// Parse the command-line flags
 	flag.Parse()
 
-	// Check required arguments (input and output paths must be provided)
-	if *inputFilePath == "" || *outputFilePath == "" {
-		fmt.Println("Usage: program -input <input_zone_file> -output <output_json_file> [-root <bind_file_root_path>] [-origin <optional_origin>]")
+	// Check required arguments (input and output paths must be provided, unless converting a named.conf)
+	// With -push the output file is optional
+	singleZone := (*inputFilePath != "" || (*axfrServer != "" && *customOrigin != "")) && (*outputFilePath != "" || (*push != "" && !*reverse))
+	if *namedConfPath == "" && !singleZone {
+		fmt.Println("Usage: program -input <input_zone_file> -output <output_json_file> [-root <bind_file_root_path>] [-origin <optional_origin>] [-skipped-report <skipped_json_file>]")
+		fmt.Println("       program -reverse -input <input_json_file> -output <output_zone_file>")
+		fmt.Println("       program -axfr <server[:port]> -origin <zone> -output <output_json_file> [-tsig [algorithm:]name:secret] [-skipped-report <skipped_json_file>]")
+		fmt.Println("       program -named-conf <named.conf> [-output-dir <dir>] [-views <view,...>] [-secrets-file <secrets_json_file>] [-root <bind_file_root_path>] [-skipped-report <skipped_json_file>]")
+		fmt.Println("       program -input <input_zone_file> -push <dry-run|create|replace> [-api-url <url>] [-api-token <token> | -api-p12 <p12_file>] [-namespace <namespace>] [-output <output_json_file>]")
 		flag.PrintDefaults()
 		return
 	}
 
+	if *reverse {
+		if err := exportBINDZone(*inputFilePath, *outputFilePath); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("Successfully wrote zone file to %s\n", *outputFilePath)
+		return
+	}
+
+	if !isValidTTLPolicy(*ttlPolicy) {
+		fmt.Printf("Invalid -ttl-policy %q, expected min, max or error\n", *ttlPolicy)
+		return
+	}
+	opts := newConversionOptions()
+	opts.TTLPolicy = *ttlPolicy
+
+	if !isValidGroupRule(*groupBy) {
+		fmt.Printf("Invalid -group-by %q, expected subdomain, include or tag\n", *groupBy)
+		return
+	}
+	opts.GroupBy = *groupBy
+
+	if _, ok := outputExtensions[*format]; !ok {
+		fmt.Printf("Invalid -format %q, expected json, yaml or terraform\n", *format)
+		return
+	}
+	opts.Format = *format
+	opts.Namespace = *namespace
+
+	if *push != "" {
+		if !isValidPushMode(*push) {
+			fmt.Printf("Invalid -push %q, expected dry-run, create or replace\n", *push)
+			return
+		}
+		client, err := newXCClientFromFlags(*apiURL, *apiToken, *apiP12)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		opts.PushClient = client
+		opts.PushMode = *push
+	}
+
+	opts.TransferServer = *axfrServer
+	if *tsig != "" {
+		key, err := parseTSIGFlag(*tsig)
+		if err != nil {
+			fmt.Printf("Invalid -tsig: %v\n", err)
+			return
+		}
+		opts.TransferKey = key
+	}
+
 	fullPath := *bindFileRootPath
 
 	// Check if the path starts with "./"
@@ -1243,26 +1104,65 @@ func main() {
 		}
 	}
 
-	// Parse the zone file with the optional origin and BIND file root path
-	_, zoneConfig, err := ParseZoneFile(*inputFilePath, *customOrigin, false, fullPath)
-	if err != nil {
-		fmt.Printf("Error parsing zone file: %v\n", err)
+	if *namedConfPath != "" {
+		opts.OutputDir = *outputDir
+		opts.SecretsFile = *secretsFile
+		var selectedViews []string
+		for _, view := range strings.Split(*views, ",") {
+			if view = strings.TrimSpace(view); view != "" {
+				selectedViews = append(selectedViews, view)
+			}
+		}
+
+		manifest, err := convertNamedConf(*namedConfPath, fullPath, selectedViews, opts)
+		if err != nil {
+			fmt.Printf("Error converting %s: %v\n", *namedConfPath, err)
+			if manifest == nil {
+				return
+			}
+		}
+
+		printManifest(manifest)
+		fmt.Printf("Wrote manifest to %s\n", filepath.Join(opts.OutputDir, "manifest.json"))
+		if opts.SecretsFile != "" {
+			if err := writeSecretsFile(opts.SecretsFile, opts.Run.Secrets); err != nil {
+				fmt.Printf("Error: %v\n", err)
+			} else {
+				fmt.Printf("Wrote %d TSIG secret(s) to %s\n", len(opts.Run.Secrets), opts.SecretsFile)
+			}
+		}
+		opts.Zone.reportSkipped(*skippedReportPath)
 		return
 	}
 
-	// Marshal the zone configuration to JSON
-	jsonBytes, err := json.MarshalIndent(zoneConfig, "", "  ")
+	var zoneConfig *ZoneConfig
+	var err error
+	if opts.TransferServer != "" {
+		// Transfer the zone from a running server
+		zoneConfig, err = TransferZone(opts.TransferServer, *customOrigin, opts.TransferKey, opts)
+	} else {
+		// Parse the zone file with the optional origin and BIND file root path
+		zoneConfig, err = ParseZoneFile(*inputFilePath, *customOrigin, fullPath, opts)
+	}
 	if err != nil {
-		fmt.Printf("Error marshaling to JSON: %v\n", err)
+		fmt.Printf("Error parsing zone file: %v\n", err)
 		return
 	}
 
-	// Write the JSON output to the specified file
-	err = os.WriteFile(*outputFilePath, jsonBytes, 0644)
-	if err != nil {
-		fmt.Printf("Error writing to output file: %v\n", err)
-		return
+	if *outputFilePath != "" {
+		if err := writeZoneConfig(zoneConfig, *outputFilePath, opts); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("Successfully wrote %s output to %s\n", outputFormatNames[opts.Format], *outputFilePath)
 	}
 
-	fmt.Printf("Successfully wrote JSON output to %s\n", *outputFilePath)
+	opts.Zone.reportSkipped(*skippedReportPath)
+
+	if opts.PushClient != nil {
+		if err := pushZone(opts.PushClient, opts.Namespace, zoneConfig, opts.PushMode); err != nil {
+			fmt.Printf("Error pushing zone: %v\n", err)
+			os.Exit(1)
+		}
+	}
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// findRRSet returns the RR set of a converted zone with the given type and
+// name, failing the test when there is none.
+func findRRSet(t *testing.T, zoneConfig *ZoneConfig, rrType, name string) DNSRecord {
+	t.Helper()
+	for _, set := range allRRSets(zoneConfig.Spec.Primary) {
+		if setType, setName := rrSetTypeAndName(set); setType == rrType && setName == name {
+			return set
+		}
+	}
+	t.Fatalf("no %s RR set named %q", rrType, name)
+	return DNSRecord{}
+}
+
+// MX records are gathered into one set per owner, their targets qualified
+// against the origin in effect.
+func TestConvertMXRecords(t *testing.T) {
+	zoneConfig := convertTestZone(t, testZoneHead+`@ IN MX 10 mail
+@ IN MX 20 mx.example.net.
+sub IN MX 5 mail.sub
+$ORIGIN sub.example.com.
+@ IN MX 10 @
+`, newConversionOptions())
+
+	for _, test := range []struct {
+		name string
+		want []MXValue
+	}{
+		{"", []MXValue{{Priority: 10, Value: "mail.example.com"}, {Priority: 20, Value: "mx.example.net"}}},
+		{"sub", []MXValue{{Priority: 5, Value: "mail.sub.example.com"}, {Priority: 10, Value: "sub.example.com"}}},
+	} {
+		set := findRRSet(t, zoneConfig, "MX", test.name)
+		if !reflect.DeepEqual(set.MXRecord.Values, test.want) {
+			t.Errorf("MX %q: got %+v, want %+v", test.name, set.MXRecord.Values, test.want)
+		}
+	}
+}
+
+func TestConvertMXRecordErrors(t *testing.T) {
+	opts := newConversionOptions()
+	convertTestZone(t, testZoneHead+"@ IN MX mail\n@ IN MX high mail\n", opts)
+
+	if len(opts.Zone.Skipped) != 2 {
+		t.Errorf("got %d skipped records, want both MX: %+v", len(opts.Zone.Skipped), opts.Zone.Skipped)
+	}
+}
+
+func TestParseTTL(t *testing.T) {
+	for _, test := range []struct {
+		value string
+		want  int
+		err   string
+	}{
+		{"3600", 3600, ""},
+		{"0", 0, ""},
+		{"1h", 3600, ""},
+		{"1w2d3h30m10s", 7*86400 + 2*86400 + 3*3600 + 30*60 + 10, ""},
+		{"1H30M", 5400, ""},
+		{"1h30", 3630, ""},
+		{"2147483647", 2147483647, ""},
+		{"2147483648", 0, "out of range"},
+		{"3551w", 0, "out of range"},
+		{"", 0, "invalid"},
+		{"1x", 0, "invalid"},
+		{"h", 0, "invalid"},
+		{"-1", 0, "invalid"},
+	} {
+		got, err := parseTTL(test.value)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("parseTTL(%q) error %v, want %q", test.value, err, test.err)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("parseTTL(%q) = %d, %v, want %d", test.value, got, err, test.want)
+		}
+	}
+}
+
+// A TTL with units is read in a record header like a plain number.
+func TestParseRecordTTLUnits(t *testing.T) {
+	records := readTestZone(t, "$ORIGIN example.com.\n$TTL 1d\nwww 1h30m IN A 192.0.2.1\nmail A 192.0.2.2\n")
+	if len(records) != 2 || records[0].TTL != 5400 || records[1].TTL != 86400 {
+		t.Errorf("got records %+v, want TTLs 5400 and 86400", records)
+	}
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// DNS wire format (RFC 1035 section 4), just enough to request a zone
+// transfer and turn the answer back into master file text.
+
+const (
+	dnsTypeSOA  = 6
+	dnsTypeTSIG = 250
+	dnsTypeAXFR = 252
+	dnsClassIN  = 1
+	dnsClassANY = 255
+
+	dnsHeaderLen = 12
+)
+
+// Mnemonics for record types seen in transfers. Anything else is written as
+// TYPEnnn, which the zone reader reports as unsupported.
+var dnsTypeNames = map[uint16]string{
+	1: "A", 2: "NS", 5: "CNAME", 6: "SOA", 12: "PTR", 13: "HINFO", 15: "MX", 16: "TXT",
+	17: "RP", 18: "AFSDB", 24: "SIG", 25: "KEY", 28: "AAAA", 29: "LOC", 33: "SRV",
+	35: "NAPTR", 37: "CERT", 39: "DNAME", 43: "DS", 44: "SSHFP", 46: "RRSIG", 47: "NSEC",
+	48: "DNSKEY", 50: "NSEC3", 51: "NSEC3PARAM", 52: "TLSA", 59: "CDS", 60: "CDNSKEY",
+	61: "OPENPGPKEY", 63: "ZONEMD", 64: "SVCB", 65: "HTTPS", 99: "SPF", 256: "URI", 257: "CAA",
+}
+
+var dnsRcodeNames = map[int]string{
+	1: "FORMERR", 2: "SERVFAIL", 3: "NXDOMAIN", 4: "NOTIMP", 5: "REFUSED", 9: "NOTAUTH",
+}
+
+// dnsRR is a resource record of a received message.
+type dnsRR struct {
+	Name        string // absolute, in presentation format
+	Type        uint16
+	Class       uint16
+	TTL         uint32
+	RData       []byte
+	Offset      int // where the record starts in the message
+	RDataOffset int
+}
+
+// dnsMessage is a received DNS message.
+type dnsMessage struct {
+	ID         uint16
+	Flags      uint16
+	Answers    []dnsRR
+	Additional []dnsRR
+}
+
+func (m *dnsMessage) rcode() int {
+	return int(m.Flags & 0x000f)
+}
+
+// appendName appends name in uncompressed wire format.
+func appendName(b []byte, name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if len(label) == 0 || len(label) > 63 {
+				return nil, fmt.Errorf("invalid label %q in %q", label, name)
+			}
+			b = append(b, byte(len(label)))
+			b = append(b, label...)
+		}
+	}
+	return append(b, 0), nil
+}
+
+// newDNSQuery builds a query for one name, type and class.
+func newDNSQuery(id uint16, name string, qtype, qclass uint16) ([]byte, error) {
+	msg := make([]byte, dnsHeaderLen)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	binary.BigEndian.PutUint16(msg[4:], 1) // QDCOUNT
+
+	msg, err := appendName(msg, name)
+	if err != nil {
+		return nil, err
+	}
+	msg = binary.BigEndian.AppendUint16(msg, qtype)
+	msg = binary.BigEndian.AppendUint16(msg, qclass)
+	return msg, nil
+}
+
+// parseDNSMessage reads the header, skips the questions and reads the answer
+// and additional records. Authority records are not used by transfers.
+func parseDNSMessage(msg []byte) (*dnsMessage, error) {
+	if len(msg) < dnsHeaderLen {
+		return nil, fmt.Errorf("short DNS message (%d bytes)", len(msg))
+	}
+
+	m := &dnsMessage{
+		ID:    binary.BigEndian.Uint16(msg[0:]),
+		Flags: binary.BigEndian.Uint16(msg[2:]),
+	}
+	qdcount := int(binary.BigEndian.Uint16(msg[4:]))
+	ancount := int(binary.BigEndian.Uint16(msg[6:]))
+	nscount := int(binary.BigEndian.Uint16(msg[8:]))
+	arcount := int(binary.BigEndian.Uint16(msg[10:]))
+
+	off := dnsHeaderLen
+	for i := 0; i < qdcount; i++ {
+		_, next, err := readDNSName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+		off = next + 4
+	}
+	if off > len(msg) {
+		return nil, fmt.Errorf("truncated question section")
+	}
+
+	for i := 0; i < ancount+nscount+arcount; i++ {
+		rr, next, err := readDNSRR(msg, off)
+		if err != nil {
+			return nil, err
+		}
+		off = next
+		switch {
+		case i < ancount:
+			m.Answers = append(m.Answers, rr)
+		case i >= ancount+nscount:
+			m.Additional = append(m.Additional, rr)
+		}
+	}
+
+	return m, nil
+}
+
+func readDNSRR(msg []byte, off int) (dnsRR, int, error) {
+	rr := dnsRR{Offset: off}
+
+	name, off, err := readDNSName(msg, off)
+	if err != nil {
+		return rr, 0, err
+	}
+	if off+10 > len(msg) {
+		return rr, 0, fmt.Errorf("truncated record %s", name)
+	}
+	rr.Name = name
+	rr.Type = binary.BigEndian.Uint16(msg[off:])
+	rr.Class = binary.BigEndian.Uint16(msg[off+2:])
+	rr.TTL = binary.BigEndian.Uint32(msg[off+4:])
+	length := int(binary.BigEndian.Uint16(msg[off+8:]))
+	off += 10
+	if off+length > len(msg) {
+		return rr, 0, fmt.Errorf("truncated rdata of %s", name)
+	}
+	rr.RDataOffset = off
+	rr.RData = msg[off : off+length]
+
+	return rr, off + length, nil
+}
+
+// readDNSName reads a possibly compressed name and returns it absolute, in
+// presentation format, with the offset following it in the message.
+func readDNSName(msg []byte, off int) (string, int, error) {
+	var name strings.Builder
+	next := -1
+
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, fmt.Errorf("name runs past the end of the message")
+		}
+		length := int(msg[off])
+
+		switch {
+		case length == 0:
+			if next == -1 {
+				next = off + 1
+			}
+			if name.Len() == 0 {
+				return ".", next, nil
+			}
+			return name.String(), next, nil
+		case length&0xc0 == 0xc0:
+			if off+1 >= len(msg) {
+				return "", 0, fmt.Errorf("truncated compression pointer")
+			}
+			if jumps++; jumps > 64 {
+				return "", 0, fmt.Errorf("compression pointer loop")
+			}
+			if next == -1 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+		case length&0xc0 != 0:
+			return "", 0, fmt.Errorf("unsupported label type 0x%02x", length)
+		default:
+			if off+1+length > len(msg) {
+				return "", 0, fmt.Errorf("label runs past the end of the message")
+			}
+			writeLabel(&name, msg[off+1:off+1+length])
+			name.WriteByte('.')
+			off += 1 + length
+		}
+	}
+}
+
+// writeLabel escapes the characters of a label that are special in master
+// files.
+func writeLabel(b *strings.Builder, label []byte) {
+	for _, c := range label {
+		switch {
+		case strings.IndexByte(".\\\"();@$ ", c) >= 0:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < '!' || c > '~':
+			fmt.Fprintf(b, "\\%03d", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+// rdataReader walks the fields of a record's rdata.
+type rdataReader struct {
+	msg []byte
+	off int
+	end int
+	err error
+}
+
+func (r *rdataReader) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (r *rdataReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if r.off+n > r.end {
+		r.fail("rdata too short")
+		return nil
+	}
+	b := r.msg[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *rdataReader) uint8() string {
+	b := r.bytes(1)
+	if b == nil {
+		return ""
+	}
+	return strconv.Itoa(int(b[0]))
+}
+
+func (r *rdataReader) uint16() string {
+	b := r.bytes(2)
+	if b == nil {
+		return ""
+	}
+	return strconv.Itoa(int(binary.BigEndian.Uint16(b)))
+}
+
+func (r *rdataReader) uint32() string {
+	b := r.bytes(4)
+	if b == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(binary.BigEndian.Uint32(b)), 10)
+}
+
+func (r *rdataReader) name() string {
+	if r.err != nil {
+		return ""
+	}
+	name, next, err := readDNSName(r.msg, r.off)
+	if err != nil {
+		r.fail("%v", err)
+		return ""
+	}
+	r.off = next
+	return name
+}
+
+// characterString reads a <character-string> and returns it quoted.
+func (r *rdataReader) characterString() string {
+	length := r.bytes(1)
+	if length == nil {
+		return ""
+	}
+	return quoteCharacterString(r.bytes(int(length[0])))
+}
+
+// rest returns the remaining rdata as hex.
+func (r *rdataReader) rest() string {
+	return strings.ToUpper(hex.EncodeToString(r.bytes(r.end - r.off)))
+}
+
+func quoteCharacterString(value []byte) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range value {
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < ' ' || c > '~':
+			fmt.Fprintf(&b, "\\%03d", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// dnsTypeName returns the mnemonic of a record type, or TYPEnnn (RFC 3597).
+func dnsTypeName(rrType uint16) string {
+	if name, exists := dnsTypeNames[rrType]; exists {
+		return name
+	}
+	return "TYPE" + strconv.Itoa(int(rrType))
+}
+
+// presentation renders a record as a master file line. Types the converter
+// does not read are written in the RFC 3597 generic form.
+func (rr dnsRR) presentation(msg []byte) (string, error) {
+	r := &rdataReader{msg: msg, off: rr.RDataOffset, end: rr.RDataOffset + len(rr.RData)}
+	var fields []string
+
+	switch rr.Type {
+	case 1, 28: // A, AAAA
+		fields = append(fields, net.IP(r.bytes(len(rr.RData))).String())
+		if len(rr.RData) != 4 && len(rr.RData) != 16 {
+			r.fail("address of %d bytes", len(rr.RData))
+		}
+	case 2, 5, 12, 39: // NS, CNAME, PTR, DNAME
+		fields = append(fields, r.name())
+	case 6: // SOA
+		fields = append(fields, r.name(), r.name(), r.uint32(), r.uint32(), r.uint32(), r.uint32(), r.uint32())
+	case 15: // MX
+		fields = append(fields, r.uint16(), r.name())
+	case 16, 99: // TXT, SPF
+		for r.err == nil && r.off < r.end {
+			fields = append(fields, r.characterString())
+		}
+	case 33: // SRV
+		fields = append(fields, r.uint16(), r.uint16(), r.uint16(), r.name())
+	case 35: // NAPTR
+		fields = append(fields, r.uint16(), r.uint16(), r.characterString(), r.characterString(), r.characterString(), r.name())
+	case 43: // DS
+		fields = append(fields, r.uint16(), r.uint8(), r.uint8(), r.rest())
+	case 44: // SSHFP
+		fields = append(fields, r.uint8(), r.uint8(), r.rest())
+	case 52: // TLSA
+		fields = append(fields, r.uint8(), r.uint8(), r.uint8(), r.rest())
+	case 257: // CAA
+		flags := r.uint8()
+		tagLength := r.bytes(1)
+		if tagLength != nil {
+			fields = append(fields, flags, string(r.bytes(int(tagLength[0]))), quoteCharacterString(r.bytes(r.end-r.off)))
+		}
+	default:
+		fields = append(fields, "\\#", strconv.Itoa(len(rr.RData)))
+		if len(rr.RData) > 0 {
+			fields = append(fields, r.rest())
+		}
+	}
+
+	if r.err == nil && r.off != r.end {
+		r.fail("%d bytes of trailing rdata", r.end-r.off)
+	}
+	if r.err != nil {
+		return "", fmt.Errorf("%s %s: %v", rr.Name, dnsTypeName(rr.Type), r.err)
+	}
+
+	return fmt.Sprintf("%s %d %s %s %s", rr.Name, rr.TTL, dnsClassName(rr.Class), dnsTypeName(rr.Type), strings.Join(fields, " ")), nil
+}
+
+func dnsClassName(class uint16) string {
+	switch class {
+	case dnsClassIN:
+		return "IN"
+	case 3:
+		return "CH"
+	case 4:
+		return "HS"
+	}
+	return "CLASS" + strconv.Itoa(int(class))
+}
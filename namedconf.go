@@ -0,0 +1,405 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// namedConfStatement is one statement of a BIND configuration file:
+//
+//	keyword [arg ...] [{ statement; ... }];
+//
+// Address match lists parse the same way, each element becoming a statement
+// with no block, which keeps the grammar small.
+type namedConfStatement struct {
+	Keyword  string
+	Args     []string
+	Block    []*namedConfStatement
+	HasBlock bool
+	File     string
+	Line     int
+}
+
+// NamedZone is a zone declared in named.conf.
+type NamedZone struct {
+	Name    string            `json:"name"`
+	Class   string            `json:"class,omitempty"`
+	Type    string            `json:"type"`
+	File    string            `json:"file,omitempty"`
+	View    string            `json:"view,omitempty"`
+	Options map[string]string `json:"options,omitempty"`
+
+	statement *namedConfStatement
+	view      *namedConfStatement // the enclosing view statement, nil outside views
+	keys      map[string]NamedKey // TSIG keys declared in the zone's view
+}
+
+// NamedConf is the result of parsing a named.conf and everything it includes.
+type NamedConf struct {
+	Path       string
+	Directory  string // options { directory "..."; }, relative zone files resolve against it
+	Zones      []NamedZone
+	Keys       map[string]NamedKey // TSIG keys by name
+	Views      []string
+	Statements []*namedConfStatement
+}
+
+type namedConfToken struct {
+	text   string
+	quoted bool
+	line   int
+}
+
+// tokenizeNamedConf splits configuration text into words, quoted strings and
+// the punctuation "{", "}" and ";". All three comment styles (//, # and
+// /* */) are dropped.
+func tokenizeNamedConf(text string) ([]namedConfToken, error) {
+	var tokens []namedConfToken
+	line := 1
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\n':
+			line++
+		case c == ' ' || c == '\t' || c == '\r':
+		case c == '#' || (c == '/' && i+1 < len(text) && text[i+1] == '/'):
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+			line++
+		case c == '/' && i+1 < len(text) && text[i+1] == '*':
+			end := strings.Index(text[i+2:], "*/")
+			if end == -1 {
+				return nil, fmt.Errorf("line %d: unterminated /* comment", line)
+			}
+			line += strings.Count(text[i:i+2+end], "\n")
+			i += end + 3
+		case c == '{' || c == '}' || c == ';':
+			tokens = append(tokens, namedConfToken{text: string(c), line: line})
+		case c == '"':
+			var value strings.Builder
+			start := line
+			i++
+			for ; i < len(text) && text[i] != '"'; i++ {
+				if text[i] == '\\' && i+1 < len(text) {
+					i++
+				}
+				if text[i] == '\n' {
+					line++
+				}
+				value.WriteByte(text[i])
+			}
+			if i >= len(text) {
+				return nil, fmt.Errorf("line %d: unterminated quoted string", start)
+			}
+			tokens = append(tokens, namedConfToken{text: value.String(), quoted: true, line: start})
+		default:
+			start := i
+			for i < len(text) && !strings.ContainsRune(" \t\r\n{};\"#", rune(text[i])) &&
+				!(text[i] == '/' && i+1 < len(text) && (text[i+1] == '/' || text[i+1] == '*')) {
+				i++
+			}
+			tokens = append(tokens, namedConfToken{text: text[start:i], line: line})
+			i--
+		}
+	}
+
+	return tokens, nil
+}
+
+// namedConfParser builds statements from tokens, following include statements.
+type namedConfParser struct {
+	conf     *NamedConf
+	included map[string]bool
+}
+
+// ParseNamedConf reads a BIND configuration file, following include
+// statements, and returns every zone it declares.
+func ParseNamedConf(path string) (*NamedConf, error) {
+	conf := &NamedConf{Path: path}
+	parser := &namedConfParser{conf: conf, included: make(map[string]bool)}
+
+	statements, err := parser.parseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	conf.Statements = statements
+	conf.collect()
+
+	return conf, nil
+}
+
+// parseNamedConfText parses configuration text that is not backed by a file,
+// such as a zone block found in a zone file. Include statements are ignored.
+func parseNamedConfText(text, name string) (*NamedConf, error) {
+	tokens, err := tokenizeNamedConf(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	conf := &NamedConf{Path: name}
+	parser := &namedConfParser{conf: conf}
+	pos := 0
+	statements, err := parser.parseStatements(tokens, &pos, name, false)
+	if err != nil {
+		return nil, err
+	}
+	conf.Statements = statements
+	conf.collect()
+
+	return conf, nil
+}
+
+func (p *namedConfParser) parseFile(path string) ([]*namedConfStatement, error) {
+	absPath, err := filepath.Abs(path)
+	if err == nil {
+		if p.included[absPath] {
+			return nil, fmt.Errorf("include loop detected at %s", path)
+		}
+		p.included[absPath] = true
+		defer delete(p.included, absPath)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+
+	tokens, err := tokenizeNamedConf(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	pos := 0
+	return p.parseStatements(tokens, &pos, path, false)
+}
+
+// parseStatements reads statements until the end of input or, when nested is
+// set, the "}" closing the enclosing block.
+func (p *namedConfParser) parseStatements(tokens []namedConfToken, pos *int, file string, nested bool) ([]*namedConfStatement, error) {
+	var statements []*namedConfStatement
+
+	for *pos < len(tokens) {
+		token := tokens[*pos]
+		*pos++
+
+		switch {
+		case token.text == "}" && !token.quoted:
+			if !nested {
+				return nil, fmt.Errorf("%s: line %d: unexpected '}'", file, token.line)
+			}
+			return statements, nil
+		case token.text == ";" && !token.quoted:
+			continue
+		case token.text == "{" && !token.quoted:
+			return nil, fmt.Errorf("%s: line %d: unexpected '{'", file, token.line)
+		}
+
+		statement := &namedConfStatement{Keyword: token.text, File: file, Line: token.line}
+		if err := p.parseStatementBody(statement, tokens, pos); err != nil {
+			return nil, err
+		}
+
+		if statement.Keyword == "include" && !token.quoted && p.included != nil {
+			included, err := p.include(statement)
+			if err != nil {
+				return nil, err
+			}
+			statements = append(statements, included...)
+			continue
+		}
+		if statement.Keyword == "options" && p.conf.Directory == "" {
+			if directory := findStatement(statement.Block, "directory"); directory != nil && len(directory.Args) > 0 {
+				p.conf.Directory = directory.Args[0]
+			}
+		}
+
+		statements = append(statements, statement)
+	}
+
+	if nested {
+		return nil, fmt.Errorf("%s: missing '}' at end of file", file)
+	}
+	return statements, nil
+}
+
+// parseStatementBody reads the arguments and optional block of a statement up
+// to its terminating ";".
+func (p *namedConfParser) parseStatementBody(statement *namedConfStatement, tokens []namedConfToken, pos *int) error {
+	for *pos < len(tokens) {
+		token := tokens[*pos]
+		*pos++
+
+		if token.quoted {
+			statement.Args = append(statement.Args, token.text)
+			continue
+		}
+
+		switch token.text {
+		case ";":
+			return nil
+		case "}":
+			// A missing ";" before the closing brace, let the caller see the brace
+			*pos--
+			return nil
+		case "{":
+			block, err := p.parseStatements(tokens, pos, statement.File, true)
+			if err != nil {
+				return err
+			}
+			statement.Block = append(statement.Block, block...)
+			statement.HasBlock = true
+		default:
+			statement.Args = append(statement.Args, token.text)
+		}
+	}
+
+	return fmt.Errorf("%s: line %d: statement %q is missing a terminating ';'", statement.File, statement.Line, statement.Keyword)
+}
+
+// include parses the file named by an include statement. Relative paths are
+// resolved against the options directory once it is known, otherwise against
+// the directory of the main configuration file.
+func (p *namedConfParser) include(statement *namedConfStatement) ([]*namedConfStatement, error) {
+	if len(statement.Args) < 1 {
+		return nil, fmt.Errorf("%s: line %d: include without a file name", statement.File, statement.Line)
+	}
+
+	path := statement.Args[0]
+	if !filepath.IsAbs(path) {
+		base := p.conf.Directory
+		if base == "" {
+			base = filepath.Dir(p.conf.Path)
+		}
+		path = filepath.Join(base, path)
+	}
+
+	return p.parseFile(path)
+}
+
+// collect gathers the zones, keys and views from the parsed statements.
+func (conf *NamedConf) collect() {
+	conf.Keys = make(map[string]NamedKey)
+	for _, statement := range conf.Statements {
+		switch statement.Keyword {
+		case "zone":
+			conf.Zones = append(conf.Zones, newNamedZone(statement))
+		case "key":
+			key := newNamedKey(statement)
+			conf.Keys[key.Name] = key
+		case "view":
+			conf.collectView(statement)
+		}
+	}
+}
+
+// collectView gathers the zones of a view block:
+//
+//	view "internal" [class] { match-clients { ... }; zone ...; };
+//
+// Zones inherit the view's class and see the keys declared in the view.
+func (conf *NamedConf) collectView(view *namedConfStatement) {
+	if len(view.Args) == 0 {
+		return
+	}
+	name := view.Args[0]
+	conf.Views = append(conf.Views, name)
+
+	keys := make(map[string]NamedKey)
+	for _, statement := range view.Block {
+		if statement.Keyword == "key" {
+			key := newNamedKey(statement)
+			keys[key.Name] = key
+		}
+	}
+
+	for _, statement := range view.Block {
+		if statement.Keyword != "zone" {
+			continue
+		}
+		zone := newNamedZone(statement)
+		zone.View = name
+		zone.view = view
+		zone.keys = keys
+		if zone.Class == "" && len(view.Args) > 1 {
+			zone.Class = strings.ToUpper(view.Args[1])
+		}
+		conf.Zones = append(conf.Zones, zone)
+	}
+}
+
+func newNamedZone(statement *namedConfStatement) NamedZone {
+	zone := NamedZone{Options: make(map[string]string), statement: statement}
+	if len(statement.Args) > 0 {
+		zone.Name = strings.TrimSuffix(statement.Args[0], ".")
+	}
+	if len(statement.Args) > 1 {
+		zone.Class = strings.ToUpper(statement.Args[1])
+	}
+
+	for _, option := range statement.Block {
+		switch option.Keyword {
+		case "type":
+			if len(option.Args) > 0 {
+				zone.Type = strings.ToLower(option.Args[0])
+			}
+		case "file":
+			if len(option.Args) > 0 {
+				zone.File = option.Args[0]
+			}
+		default:
+			zone.Options[option.Keyword] = option.valueString()
+		}
+	}
+
+	return zone
+}
+
+// valueString renders a statement's arguments and block back as text, e.g.
+// "{ 10.0.0.1; key xfr; }".
+func (statement *namedConfStatement) valueString() string {
+	parts := append([]string{}, statement.Args...)
+	if statement.HasBlock {
+		var block []string
+		for _, element := range statement.Block {
+			block = append(block, strings.TrimSpace(element.Keyword+" "+element.valueString())+";")
+		}
+		parts = append(parts, "{ "+strings.Join(block, " ")+" }")
+	}
+	return strings.Join(parts, " ")
+}
+
+// findStatement returns the first statement with the given keyword.
+func findStatement(statements []*namedConfStatement, keyword string) *namedConfStatement {
+	for _, statement := range statements {
+		if statement.Keyword == keyword {
+			return statement
+		}
+	}
+	return nil
+}
+
+// scopes returns the statements a zone's references resolve against: those of
+// its view first, then the top-level ones.
+func (conf *NamedConf) scopes(zone NamedZone) [][]*namedConfStatement {
+	if zone.view == nil {
+		return [][]*namedConfStatement{conf.Statements}
+	}
+	return [][]*namedConfStatement{zone.view.Block, conf.Statements}
+}
+
+// ZoneFilePath resolves a zone's file against the options directory, or root
+// when the configuration has no directory option.
+func (conf *NamedConf) ZoneFilePath(zone NamedZone, root string) string {
+	if zone.File == "" || filepath.IsAbs(zone.File) {
+		return zone.File
+	}
+	base := conf.Directory
+	if base == "" {
+		base = root
+	}
+	return filepath.Join(base, zone.File)
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestFiles writes files into a temporary directory and returns it.
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestTokenizeNamedConfComments(t *testing.T) {
+	tokens, err := tokenizeNamedConf(`# hash
+zone "example.com" { // slashes
+	type master; /* block
+	comment */ file "db.example.com";
+	also-notify { 192.0.2.1; };
+};`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var texts []string
+	for _, token := range tokens {
+		texts = append(texts, token.text)
+	}
+	want := `zone example.com { type master ; file db.example.com ; also-notify { 192.0.2.1 ; } ; } ;`
+	if got := strings.Join(texts, " "); got != want {
+		t.Errorf("got tokens %s, want %s", got, want)
+	}
+	if tokens[7].text != "db.example.com" || !tokens[7].quoted || tokens[7].line != 4 {
+		t.Errorf("got token %+v, want the quoted file name on line 4", tokens[7])
+	}
+}
+
+func TestTokenizeNamedConfErrors(t *testing.T) {
+	for _, text := range []string{`zone "example.com`, "zone /* never closed"} {
+		if _, err := tokenizeNamedConf(text); err == nil {
+			t.Errorf("tokenizeNamedConf(%q) succeeded, want an error", text)
+		}
+	}
+}
+
+func TestParseNamedConf(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"named.conf": `include "zones.conf";
+options {
+	directory "/var/named";
+	allow-transfer { none; };
+};
+key "xfr" { algorithm hmac-sha256; secret "c2VjcmV0"; };
+zone "." { type hint; file "named.ca"; };
+`,
+		"zones.conf": `zone "example.com" IN {
+	type master;
+	file "db.example.com";
+	allow-update { key xfr; };
+	notify yes;
+};
+zone "example.net." { type slave; masters { 192.0.2.1; }; file "slaves/example.net"; };
+`,
+	})
+
+	conf, err := ParseNamedConf(filepath.Join(dir, "named.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Directory != "/var/named" {
+		t.Errorf("got directory %q, want /var/named", conf.Directory)
+	}
+	if _, exists := conf.Keys["xfr"]; !exists {
+		t.Error("key xfr not collected")
+	}
+
+	want := []NamedZone{
+		{Name: "example.com", Class: "IN", Type: "master", File: "db.example.com"},
+		{Name: "example.net", Type: "slave", File: "slaves/example.net"},
+		{Name: "", Type: "hint", File: "named.ca"},
+	}
+	if len(conf.Zones) != len(want) {
+		t.Fatalf("got %d zones, want %d", len(conf.Zones), len(want))
+	}
+	for i, zone := range conf.Zones {
+		if zone.Name != want[i].Name || zone.Class != want[i].Class || zone.Type != want[i].Type || zone.File != want[i].File {
+			t.Errorf("zone %d: got %+v, want %+v", i, zone, want[i])
+		}
+	}
+
+	options := conf.Zones[0].Options
+	if options["allow-update"] != "{ key xfr; }" || options["notify"] != "yes" {
+		t.Errorf("got options %v", options)
+	}
+	if got := conf.ZoneFilePath(conf.Zones[0], "/root"); got != "/var/named/db.example.com" {
+		t.Errorf("got zone file %s, want it under the options directory", got)
+	}
+	if line := conf.Zones[1].statement.Line; line != 7 || filepath.Base(conf.Zones[1].statement.File) != "zones.conf" {
+		t.Errorf("zone read from %s line %d, want zones.conf line 7", conf.Zones[1].statement.File, line)
+	}
+}
+
+func TestZoneFilePathWithoutDirectory(t *testing.T) {
+	conf := &NamedConf{}
+	for _, test := range []struct {
+		file, want string
+	}{
+		{"db.example.com", "/srv/bind/db.example.com"},
+		{"/etc/bind/db.example.com", "/etc/bind/db.example.com"},
+		{"", ""},
+	} {
+		if got := conf.ZoneFilePath(NamedZone{File: test.file}, "/srv/bind"); got != test.want {
+			t.Errorf("ZoneFilePath(%q) = %q, want %q", test.file, got, test.want)
+		}
+	}
+}
+
+func TestParseNamedConfErrors(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{"missing brace", map[string]string{"named.conf": `zone "example.com" { type master;`}, "missing '}'"},
+		{"stray brace", map[string]string{"named.conf": `};`}, "unexpected '}'"},
+		{"missing semicolon", map[string]string{"named.conf": `zone "example.com" { type master; }`}, "terminating ';'"},
+		{"include loop", map[string]string{"named.conf": `include "a.conf";`, "a.conf": `include "named.conf";`}, "include loop"},
+		{"missing include", map[string]string{"named.conf": `include "missing.conf";`}, "failed to open"},
+	} {
+		dir := writeTestFiles(t, test.files)
+		_, err := ParseNamedConf(filepath.Join(dir, "named.conf"))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+		}
+	}
+}
+
+// Zones of a view carry its name and class and see its keys, top-level zones
+// none of them.
+func TestParseNamedConfViews(t *testing.T) {
+	conf, err := parseNamedConfText(`key "global" { algorithm hmac-sha256; secret "c2VjcmV0"; };
+view "internal" {
+	match-clients { 10.0.0.0/8; };
+	key "inside" { algorithm hmac-sha256; secret "c2VjcmV0"; };
+	zone "example.com" { type master; file "internal/db.example.com"; };
+};
+view "external" CH {
+	zone "example.com" { type master; file "external/db.example.com"; };
+};
+zone "example.org" { type master; file "db.example.org"; };
+`, "named.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(conf.Views, ",") != "internal,external" {
+		t.Errorf("got views %v, want internal and external", conf.Views)
+	}
+	want := []struct {
+		view, file, class string
+		keys              int
+	}{
+		{"internal", "internal/db.example.com", "", 1},
+		{"external", "external/db.example.com", "CH", 0},
+		{"", "db.example.org", "", 0},
+	}
+	if len(conf.Zones) != len(want) {
+		t.Fatalf("got %d zones, want %d", len(conf.Zones), len(want))
+	}
+	for i, zone := range conf.Zones {
+		if zone.View != want[i].view || zone.File != want[i].file || zone.Class != want[i].class || len(zone.keys) != want[i].keys {
+			t.Errorf("zone %d: got view %q file %q class %q with %d keys, want %+v", i, zone.View, zone.File, zone.Class, len(zone.keys), want[i])
+		}
+	}
+	if _, exists := conf.Keys["inside"]; exists {
+		t.Error("view key collected as a global key")
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+// conversionOptions are the settings of one run, filled in from the flags of
+// the command or subcommand, and the state the conversion collects. They are
+// passed down to every step of a conversion rather than kept in package
+// variables.
+type conversionOptions struct {
+	TTLPolicy string // -ttl-policy
+	GroupBy   string // -group-by rule, empty keeps every RR set in the default group
+	OutputDir string // -output-dir, also where zones of zone blocks are written
+	Format    string // -format, one of the Format constants
+	Namespace string // -namespace zones are pushed to and written with
+
+	// -secrets-file, TSIG secrets are written there instead of into the zones
+	SecretsFile string
+
+	// Server and key set by -axfr and -tsig. In -named-conf mode primary zones
+	// are transferred from this server instead of read from their files.
+	TransferServer string
+	TransferKey    *NamedKey
+
+	// Client and mode for -push, PushClient is nil without -push
+	PushClient *xcClient
+	PushMode   string
+
+	// Zone collects what happens to the records of the zone being converted,
+	// Run what spans all zones of the run
+	Zone *zoneState
+	Run  *runState
+}
+
+// zoneState is what the conversion of one zone collects besides its RR sets.
+// A named.conf run gives every zone its own.
+type zoneState struct {
+	processedFiles map[string]bool // zone files read, a zone block file is read once
+	Skipped        []SkippedRecord // records that were not converted
+	Adjusted       []SkippedRecord // records converted with a value changed to fit XC
+}
+
+func newZoneState() *zoneState {
+	return &zoneState{processedFiles: make(map[string]bool)}
+}
+
+// add appends what another zone's conversion collected, for the report of a
+// whole run.
+func (z *zoneState) add(other *zoneState) {
+	z.Skipped = append(z.Skipped, other.Skipped...)
+	z.Adjusted = append(z.Adjusted, other.Adjusted...)
+}
+
+// runState is what a run collects across all of its zones.
+type runState struct {
+	Secrets []ZoneSecret // TSIG secrets left out of the outputs with -secrets-file
+
+	// Views each zone name was pushed from, XC has one zone of a name per
+	// namespace
+	Pushed map[string]string
+}
+
+// newConversionOptions returns the options of a run without any flags.
+func newConversionOptions() conversionOptions {
+	return conversionOptions{
+		TTLPolicy: TTLPolicyMin,
+		OutputDir: ".",
+		Format:    FormatJSON,
+		Namespace: "system",
+		PushMode:  PushDryRun,
+		Zone:      newZoneState(),
+		Run:       &runState{Pushed: make(map[string]string)},
+	}
+}
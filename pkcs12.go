@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// errPKCS12WrongPassword is reported when the .p12 password does not match.
+var errPKCS12WrongPassword = errors.New("wrong password for the certificate file")
+
+// loadP12Certificate reads a client certificate and its key from a .p12 file.
+func loadP12Certificate(path, password string) (tls.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to open file: %v", err)
+	}
+	certificate, err := decodePKCS12(data, password)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return certificate, nil
+}
+
+// decodePKCS12 returns the private key and certificates of a PKCS#12 file,
+// the certificate matching the key first.
+func decodePKCS12(data []byte, password string) (tls.Certificate, error) {
+	key, certificate, caCertificates, err := pkcs12.DecodeChain(data, password)
+	if errors.Is(err, pkcs12.ErrIncorrectPassword) {
+		return tls.Certificate{}, errPKCS12WrongPassword
+	}
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	result := tls.Certificate{PrivateKey: key}
+	var chain [][]byte
+	for _, certificate := range append([]*x509.Certificate{certificate}, caCertificates...) {
+		if result.Leaf == nil && publicKeyMatches(certificate.PublicKey, key) {
+			result.Leaf = certificate
+			continue
+		}
+		chain = append(chain, certificate.Raw)
+	}
+	if result.Leaf == nil {
+		return tls.Certificate{}, fmt.Errorf("the certificate file holds no certificate for its private key")
+	}
+	result.Certificate = append([][]byte{result.Leaf.Raw}, chain...)
+
+	return result, nil
+}
+
+func publicKeyMatches(publicKey crypto.PublicKey, key crypto.PrivateKey) bool {
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return key.PublicKey.Equal(publicKey)
+	case *ecdsa.PrivateKey:
+		return key.PublicKey.Equal(publicKey)
+	case ed25519.PrivateKey:
+		return key.Public().(ed25519.PublicKey).Equal(publicKey)
+	}
+	return false
+}
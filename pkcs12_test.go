@@ -0,0 +1,69 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"strings"
+	"testing"
+)
+
+// The files in testdata were made with OpenSSL 3:
+//
+//	openssl pkcs12 -export -certpbe PBE-SHA1-3DES -keypbe PBE-SHA1-3DES -macalg sha1 -passout pass:s3cr3t
+//	openssl pkcs12 -export -certpbe AES-256-CBC -keypbe AES-256-CBC -macalg sha256 -passout pass:s3cr3t
+//	openssl pkcs12 -export -legacy -passout pass:s3cr3t
+const testP12Password = "s3cr3t"
+
+func TestLoadP12Certificate(t *testing.T) {
+	for _, test := range []struct {
+		file       string
+		commonName string
+		keyType    string
+	}{
+		{"testdata/legacy-3des.p12", "bindtoxcdns-test-3des", "rsa"},
+		{"testdata/pbes2-aes256.p12", "bindtoxcdns-test-aes", "ecdsa"},
+		{"testdata/legacy-rc2.p12", "bindtoxcdns-test-3des", "rsa"},
+	} {
+		certificate, err := loadP12Certificate(test.file, testP12Password)
+		if err != nil {
+			t.Errorf("%s: %v", test.file, err)
+			continue
+		}
+		if len(certificate.Certificate) == 0 {
+			t.Errorf("%s: no certificate", test.file)
+			continue
+		}
+		leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+		if err != nil {
+			t.Errorf("%s: %v", test.file, err)
+			continue
+		}
+		if leaf.Subject.CommonName != test.commonName {
+			t.Errorf("%s: got certificate %q, want %q", test.file, leaf.Subject.CommonName, test.commonName)
+		}
+
+		keyType := ""
+		switch certificate.PrivateKey.(type) {
+		case *rsa.PrivateKey:
+			keyType = "rsa"
+		case *ecdsa.PrivateKey:
+			keyType = "ecdsa"
+		}
+		if keyType != test.keyType {
+			t.Errorf("%s: got key %T, want %s", test.file, certificate.PrivateKey, test.keyType)
+		}
+		if !publicKeyMatches(leaf.PublicKey, certificate.PrivateKey) {
+			t.Errorf("%s: key does not match the certificate", test.file)
+		}
+	}
+}
+
+func TestLoadP12CertificateWrongPassword(t *testing.T) {
+	for _, file := range []string{"testdata/legacy-3des.p12", "testdata/pbes2-aes256.p12", "testdata/legacy-rc2.p12"} {
+		_, err := loadP12Certificate(file, "wrong")
+		if err == nil || !strings.Contains(err.Error(), errPKCS12WrongPassword.Error()) {
+			t.Errorf("%s: got error %v, want a wrong password", file, err)
+		}
+	}
+}
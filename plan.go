@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// zonePlan is the change needed to bring an XC zone in line with a converted
+// zone.
+type zonePlan struct {
+	Desired     *ZoneConfig
+	Current     *ZoneConfig // nil when the zone does not exist in XC yet
+	Replace     bool        // the zone changes between primary and secondary
+	Differences []zoneDifference
+	Unreadable  int // RR sets in XC of types bindtoxcdns does not know, apply drops them
+}
+
+// hasChanges reports whether applying the plan changes anything.
+func (plan *zonePlan) hasChanges() bool {
+	return plan.Current == nil || plan.Replace || len(plan.Differences) > 0 || plan.Unreadable > 0
+}
+
+// isFieldChange tells zone settings apart from records in a plan.
+func isFieldChange(difference zoneDifference) bool {
+	return difference.Record.Type == "SOA" || difference.Record.Type == "SECONDARY"
+}
+
+// planZone compares the zone in XC with the converted zone.
+func planZone(client *xcClient, namespace string, desired *ZoneConfig) (*zonePlan, error) {
+	desired.Metadata.Namespace = namespace
+	current, err := client.GetZone(namespace, desired.Metadata.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &zonePlan{Desired: desired, Current: current}
+	if current != nil {
+		plan.Replace = (current.Spec.Primary == nil) != (desired.Spec.Primary == nil)
+	}
+
+	switch {
+	case current == nil || plan.Replace:
+		// Every record is added, the settings are printed from the zone itself
+		if desired.Spec.Primary != nil {
+			empty := &ZoneConfig{}
+			empty.Spec.Primary = &PrimaryZone{}
+			var differences []zoneDifference
+			differences, err = diffZones(empty, desired)
+			for _, difference := range differences {
+				if !isFieldChange(difference) {
+					plan.Differences = append(plan.Differences, difference)
+				}
+			}
+		}
+	case desired.Spec.Primary != nil:
+		// Sets of other types decode without any record and cannot be diffed,
+		// but the PUT of the whole spec removes them all the same
+		readable := *current
+		primary := *current.Spec.Primary
+		primary.DefaultRRSetGroup = withoutEmptyRRSets(primary.DefaultRRSetGroup)
+		primary.RRSetGroup = nil
+		for _, group := range current.Spec.Primary.RRSetGroup {
+			group.RRSet = withoutEmptyRRSets(group.RRSet)
+			primary.RRSetGroup = append(primary.RRSetGroup, group)
+		}
+		readable.Spec.Primary = &primary
+		plan.Unreadable = len(allRRSets(current.Spec.Primary)) - len(allRRSets(&primary))
+		plan.Differences, err = diffZones(&readable, desired)
+	default:
+		plan.Differences = secondaryDifferences(current.Spec.Secondary, desired.Spec.Secondary)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// secondaryDifferences compares the transfer settings of secondary zones.
+// Secrets cannot be read back from XC, so only key names are compared.
+func secondaryDifferences(current, desired *SecondaryZone) []zoneDifference {
+	if current == nil {
+		current = &SecondaryZone{}
+	}
+	var differences []zoneDifference
+	for _, field := range []struct {
+		name             string
+		current, desired string
+	}{
+		{"primary_servers", strings.Join(current.PrimaryServers, ","), strings.Join(desired.PrimaryServers, ",")},
+		{"tsig_key_name", current.TSIGKeyName, desired.TSIGKeyName},
+		{"tsig_key_algorithm", current.TSIGKeyAlgorithm, desired.TSIGKeyAlgorithm},
+	} {
+		if field.current != field.desired {
+			differences = append(differences, zoneDifference{
+				Change: "~",
+				Record: bindRecord{Owner: "@", Type: "SECONDARY", RData: fmt.Sprintf("%s %q -> %q", field.name, field.current, field.desired)},
+			})
+		}
+	}
+	return differences
+}
+
+// zoneSettings lists the settings a new zone is created with.
+func zoneSettings(zoneConfig *ZoneConfig) []string {
+	if secondary := zoneConfig.Spec.Secondary; secondary != nil {
+		settings := []string{"primary_servers " + strings.Join(secondary.PrimaryServers, ",")}
+		if secondary.TSIGKeyName != "" {
+			settings = append(settings, "tsig_key_name "+secondary.TSIGKeyName, "tsig_key_algorithm "+secondary.TSIGKeyAlgorithm)
+		}
+		return settings
+	}
+	if zoneConfig.Spec.Primary == nil {
+		return nil
+	}
+	soa := zoneConfig.Spec.Primary.SOAParameters
+	return []string{
+		fmt.Sprintf("soa_parameters refresh %d, retry %d, expire %d, negative_ttl %d, ttl %d", soa.Refresh, soa.Retry, soa.Expire, soa.NegativeTTL, soa.TTL),
+	}
+}
+
+// printPlan prints a plan the way terraform plan does.
+func printPlan(plan *zonePlan) {
+	name := plan.Desired.Metadata.Name
+	if !plan.hasChanges() {
+		fmt.Printf("No changes. dns_zone %s in namespace %s matches the zone file.\n", name, plan.Desired.Metadata.Namespace)
+		return
+	}
+
+	fmt.Println("bindtoxcdns will perform the following actions:")
+	fmt.Println()
+	switch {
+	case plan.Current == nil:
+		fmt.Printf("  # dns_zone %s will be created\n", name)
+		fmt.Printf(ColorGreen+"  + dns_zone %q {"+ColorReset+"\n", name)
+	case plan.Replace:
+		fmt.Printf("  # dns_zone %s must be replaced, it changes between primary and secondary\n", name)
+		fmt.Println("  # apply will not do this, delete the zone in XC first")
+		fmt.Printf(ColorRed+"-/+"+ColorReset+" dns_zone %q {\n", name)
+	default:
+		fmt.Printf("  # dns_zone %s will be updated in-place\n", name)
+		fmt.Println("  # apply replaces the whole spec, records in XC that are not in the zone file are removed")
+		fmt.Printf(ColorYellow+"  ~ dns_zone %q {"+ColorReset+"\n", name)
+	}
+
+	if plan.Current == nil || plan.Replace {
+		for _, setting := range zoneSettings(plan.Desired) {
+			fmt.Printf(ColorGreen+"      + %s"+ColorReset+"\n", setting)
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, difference := range plan.Differences {
+		record := difference.Record
+		if isFieldChange(difference) {
+			fmt.Printf(ColorYellow+"      ~ %s"+ColorReset+"\n", record.RData)
+			continue
+		}
+		counts[difference.Change]++
+		switch difference.Change {
+		case "+":
+			fmt.Printf(ColorGreen+"      + %s %d %s %s"+ColorReset+"\n", record.Owner, record.TTL, record.Type, record.RData)
+		case "-":
+			fmt.Printf(ColorRed+"      - %s %d %s %s"+ColorReset+"\n", record.Owner, record.TTL, record.Type, record.RData)
+		default:
+			fmt.Printf(ColorYellow+"      ~ %s %s %s ttl %d -> %d"+ColorReset+"\n", record.Owner, record.Type, record.RData, difference.OldTTL, record.TTL)
+		}
+	}
+	if plan.Unreadable > 0 {
+		fmt.Printf(ColorRed+"      - %d RR set(s) of record types bindtoxcdns cannot show"+ColorReset+"\n", plan.Unreadable)
+	}
+	fmt.Println("    }")
+	fmt.Println()
+	summary := fmt.Sprintf("Plan: %d record(s) to add, %d to change, %d to remove", counts["+"], counts["~"], counts["-"])
+	if plan.Unreadable > 0 {
+		summary += fmt.Sprintf(" and %d RR set(s) of other types", plan.Unreadable)
+	}
+	fmt.Println(summary + ".")
+}
+
+// applyPlan writes the converted zone to XC. An existing zone keeps its
+// metadata (labels, annotations, description), only its spec is replaced.
+// A zone changing between primary and secondary is refused, XC cannot change
+// the type of a zone in place and deleting it would take it offline.
+func applyPlan(client *xcClient, plan *zonePlan) error {
+	desired := plan.Desired
+	if plan.Current == nil {
+		return client.CreateZone(desired)
+	}
+	if plan.Replace {
+		return fmt.Errorf("dns_zone %s changes between primary and secondary, which XC cannot do in place; delete it in namespace %s first and run apply again", desired.Metadata.Name, desired.Metadata.Namespace)
+	}
+
+	desired.Metadata.Labels = plan.Current.Metadata.Labels
+	desired.Metadata.Annotations = plan.Current.Metadata.Annotations
+	desired.Metadata.Description = plan.Current.Metadata.Description
+	return client.ReplaceZone(desired)
+}
+
+// confirmApply asks for the same "yes" terraform apply does.
+func confirmApply() bool {
+	fmt.Println()
+	fmt.Println("Do you want to perform these actions?")
+	fmt.Println("  Only 'yes' will be accepted to approve.")
+	fmt.Print("\n  Enter a value: ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(answer) == "yes"
+}
+
+// runPlan implements the plan and apply subcommands:
+//
+//	bindtoxcdns plan  -input <zone file> [-origin zone] [-namespace ns] [api flags]
+//	bindtoxcdns apply -input <zone file> [-origin zone] [-namespace ns] [-auto-approve] [api flags]
+//
+// plan exits with status 2 when there are changes, like terraform plan
+// -detailed-exitcode, so scripts can tell drift apart from errors (1).
+func runPlan(command string, args []string) int {
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	inputFilePath := flags.String("input", "", "Path to the input zone file")
+	customOrigin := flags.String("origin", "", "Optional origin to override $ORIGIN in the zone file")
+	bindFileRootPath := flags.String("root", ".", "BIND file root path for resolving file references")
+	ttlPolicy := flags.String("ttl-policy", TTLPolicyMin, "TTL to use when records in the same RR set disagree: min, max or error")
+	groupBy := flags.String("group-by", "", "Split RR sets into named rr_set_groups: subdomain, include or tag")
+	namespace := flags.String("namespace", "system", "XC namespace of the zone")
+	apiURL := flags.String("api-url", "", "XC API URL, e.g. https://tenant.console.ves.volterra.io/api (default $VOLT_API_URL)")
+	apiToken := flags.String("api-token", "", "XC API token (default $VOLTERRA_TOKEN)")
+	apiP12 := flags.String("api-p12", "", "XC API certificate as a .p12 file, its password is read from $VES_P12_PASSWORD (default $VOLT_API_P12_FILE)")
+	autoApprove := false
+	if command == "apply" {
+		flags.BoolVar(&autoApprove, "auto-approve", false, "Apply without asking for confirmation")
+	}
+	flags.Usage = func() {
+		fmt.Printf("Usage: program %s -input <input_zone_file> [-origin <optional_origin>] [-root <bind_file_root_path>] [-namespace <namespace>] [-api-url <url>] [-api-token <token> | -api-p12 <p12_file>]\n", command)
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *inputFilePath == "" || flags.NArg() != 0 {
+		flags.Usage()
+		return 1
+	}
+	if !isValidTTLPolicy(*ttlPolicy) {
+		fmt.Printf("Invalid -ttl-policy %q, expected min, max or error\n", *ttlPolicy)
+		return 1
+	}
+	opts := newConversionOptions()
+	opts.TTLPolicy = *ttlPolicy
+	if !isValidGroupRule(*groupBy) {
+		fmt.Printf("Invalid -group-by %q, expected subdomain, include or tag\n", *groupBy)
+		return 1
+	}
+	opts.GroupBy = *groupBy
+
+	client, err := newXCClientFromFlags(*apiURL, *apiToken, *apiP12)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	desired, err := ParseZoneFile(*inputFilePath, *customOrigin, *bindFileRootPath, opts)
+	if err != nil {
+		fmt.Printf("Error parsing zone file: %v\n", err)
+		return 1
+	}
+	opts.Zone.printSkippedSummary()
+
+	plan, err := planZone(client, *namespace, desired)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	fmt.Println()
+	printPlan(plan)
+	if !plan.hasChanges() {
+		return 0
+	}
+	if command == "plan" {
+		return 2
+	}
+
+	if !autoApprove && !confirmApply() {
+		fmt.Println("\nApply cancelled.")
+		return 1
+	}
+
+	if err := applyPlan(client, plan); err != nil {
+		reportAPIValidationErrors(err, desired)
+		fmt.Printf("Error applying plan: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("\nApply complete! dns_zone %s in namespace %s is up to date.\n", desired.Metadata.Name, *namespace)
+	return 0
+}
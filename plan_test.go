@@ -0,0 +1,136 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPlanAndApplyCreate(t *testing.T) {
+	fake := newFakeXC(t)
+	client := fake.client(t, testAPIToken)
+
+	plan, err := planZone(client, "system", testPrimaryZone("example.com", "192.0.2.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Current != nil || plan.Replace || !plan.hasChanges() {
+		t.Fatalf("got plan %+v, want a create", plan)
+	}
+	if len(plan.Differences) != 1 || plan.Differences[0].Change != "+" || plan.Differences[0].Record.Type != "A" {
+		t.Errorf("got differences %+v, want the A record added", plan.Differences)
+	}
+
+	if err := applyPlan(client, plan); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(fake.methods(), " "); got != "GET POST" {
+		t.Errorf("got requests %s, want GET POST", got)
+	}
+	if fake.zones["system/example.com"] == nil {
+		t.Error("zone was not created")
+	}
+}
+
+func TestPlanAndApplyUpdateInPlace(t *testing.T) {
+	fake := newFakeXC(t)
+	client := fake.client(t, testAPIToken)
+
+	current := testPrimaryZone("example.com", "192.0.2.1")
+	current.Metadata.Namespace = "system"
+	current.Metadata.Labels["team"] = "dns"
+	current.Metadata.Annotations["owner"] = "netops"
+	current.Metadata.Description = "managed in XC"
+	fake.zones["system/example.com"] = current
+
+	plan, err := planZone(client, "system", testPrimaryZone("example.com", "192.0.2.2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Current == nil || plan.Replace || !plan.hasChanges() {
+		t.Fatalf("got plan %+v, want an update in place", plan)
+	}
+	changes := make(map[string]int)
+	for _, difference := range plan.Differences {
+		changes[difference.Change]++
+	}
+	if changes["+"] != 1 || changes["-"] != 1 {
+		t.Errorf("got differences %+v, want one address added and one removed", plan.Differences)
+	}
+
+	if err := applyPlan(client, plan); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(fake.methods(), " "); got != "GET PUT" {
+		t.Errorf("got requests %s, want GET PUT", got)
+	}
+
+	// The spec is replaced, the metadata kept
+	zone := fake.zones["system/example.com"]
+	if address := zone.Spec.Primary.DefaultRRSetGroup[0].ARecord.Values[0]; address != "192.0.2.2" {
+		t.Errorf("got address %s, want 192.0.2.2", address)
+	}
+	if zone.Metadata.Labels["team"] != "dns" || zone.Metadata.Annotations["owner"] != "netops" || zone.Metadata.Description != "managed in XC" {
+		t.Errorf("metadata not kept: %+v", zone.Metadata)
+	}
+
+	// Applied, nothing is left to change
+	plan, err = planZone(client, "system", testPrimaryZone("example.com", "192.0.2.2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.hasChanges() {
+		t.Errorf("got differences %+v after apply, want none", plan.Differences)
+	}
+}
+
+func TestPlanAndApplyRefusesReplace(t *testing.T) {
+	fake := newFakeXC(t)
+	client := fake.client(t, testAPIToken)
+
+	current := newZoneConfig()
+	current.Metadata.Name = "example.com"
+	current.Spec.Secondary = &SecondaryZone{PrimaryServers: []string{"192.0.2.53"}}
+	fake.zones["system/example.com"] = current
+
+	plan, err := planZone(client, "system", testPrimaryZone("example.com", "192.0.2.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.Replace || !plan.hasChanges() {
+		t.Fatalf("got plan %+v, want a replace", plan)
+	}
+
+	err = applyPlan(client, plan)
+	if err == nil || !strings.Contains(err.Error(), "between primary and secondary") {
+		t.Errorf("got error %v, want the replace refused", err)
+	}
+	if got := strings.Join(fake.methods(), " "); got != "GET" {
+		t.Errorf("got requests %s, want only the GET of the plan", got)
+	}
+	if fake.zones["system/example.com"].Spec.Secondary == nil {
+		t.Error("secondary zone was changed")
+	}
+}
+
+// RR sets of types that cannot be read back are dropped by the PUT of the
+// whole spec, so the plan counts them as removed.
+func TestPlanCountsUnreadableRRSets(t *testing.T) {
+	fake := newFakeXC(t)
+	client := fake.client(t, testAPIToken)
+
+	current := testPrimaryZone("example.com", "192.0.2.1")
+	current.Metadata.Namespace = "system"
+	current.Spec.Primary.DefaultRRSetGroup = append(current.Spec.Primary.DefaultRRSetGroup, DNSRecord{TTL: 300})
+	fake.zones["system/example.com"] = current
+
+	plan, err := planZone(client, "system", testPrimaryZone("example.com", "192.0.2.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Differences) != 0 {
+		t.Errorf("got differences %+v, want none", plan.Differences)
+	}
+	if plan.Unreadable != 1 || !plan.hasChanges() {
+		t.Errorf("got %d unreadable RR sets, changes %v, want 1 to remove", plan.Unreadable, plan.hasChanges())
+	}
+}
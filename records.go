@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// XC enum names for the numeric fields of SSHFP, TLSA and DS records.
+var (
+	sshfpAlgorithms = map[int]string{
+		1: "RSA",
+		2: "DSA",
+		3: "ECDSA",
+		4: "Ed25519",
+	}
+
+	tlsaCertificateUsages = map[int]string{
+		0: "CertificateAuthoritySupport",
+		1: "ServiceCertificateConstraint",
+		2: "TrustAnchorAssertion",
+		3: "DomainIssuedCertificate",
+	}
+	tlsaSelectors = map[int]string{
+		0: "FullCertificate",
+		1: "UseSubjectPublicKey",
+	}
+	tlsaMatchingTypes = map[int]string{
+		0: "NoHash",
+		1: "SHA256",
+		2: "SHA512",
+	}
+
+	dsKeyAlgorithms = map[int]string{
+		5:  "RSASHA1",
+		7:  "RSASHA1NSEC3SHA1",
+		8:  "RSASHA256",
+		10: "RSASHA512",
+		13: "ECDSAP256SHA256",
+		14: "ECDSAP384SHA384",
+		15: "ED25519",
+		16: "ED448",
+	}
+)
+
+// parseUint parses a decimal field of a record and checks it fits in max.
+func parseUint(field, value string, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n > max {
+		return 0, fmt.Errorf("invalid %s %q, expected 0-%d", field, value, max)
+	}
+	return n, nil
+}
+
+// parseHexField joins the remaining rdata tokens, which may be split by
+// whitespace in the presentation format, and checks they form valid hex of
+// the expected length. A length of 0 accepts any non-empty hex string.
+func parseHexField(field string, tokens []string, length int) (string, error) {
+	value := strings.ToLower(strings.Join(tokens, ""))
+	if value == "" {
+		return "", fmt.Errorf("missing %s", field)
+	}
+	if _, err := hex.DecodeString(value); err != nil {
+		return "", fmt.Errorf("invalid %s %q, expected hex", field, value)
+	}
+	if length > 0 && len(value) != length {
+		return "", fmt.Errorf("invalid %s length %d, expected %d hex digits", field, len(value), length)
+	}
+	return value, nil
+}
+
+// processNAPTR parses NAPTR rdata, <order> <preference> <flags> <service>
+// <regexp> <replacement>. The replacement is qualified against origin.
+func processNAPTR(rdata []string, origin string) (NAPTRValue, error) {
+	if len(rdata) != 6 {
+		return NAPTRValue{}, fmt.Errorf("invalid NAPTR record format: expected <order> <preference> <flags> <service> <regexp> <replacement>")
+	}
+
+	order, err := parseUint("NAPTR order", rdata[0], 65535)
+	if err != nil {
+		return NAPTRValue{}, err
+	}
+	preference, err := parseUint("NAPTR preference", rdata[1], 65535)
+	if err != nil {
+		return NAPTRValue{}, err
+	}
+
+	flags := strings.ToUpper(rdata[2])
+	for _, r := range flags {
+		if !('A' <= r && r <= 'Z') && !('0' <= r && r <= '9') {
+			return NAPTRValue{}, fmt.Errorf("invalid NAPTR flags %q", rdata[2])
+		}
+	}
+
+	// Regexp and replacement are mutually exclusive, "." means no replacement
+	replacement := "."
+	if rdata[5] != "." {
+		replacement = qualifyName(rdata[5], origin)
+	}
+	if rdata[4] != "" && replacement != "." {
+		return NAPTRValue{}, fmt.Errorf("invalid NAPTR record: regexp and replacement cannot both be set")
+	}
+
+	return NAPTRValue{
+		Order:       order,
+		Preference:  preference,
+		Flags:       flags,
+		Service:     rdata[3],
+		Regexp:      rdata[4],
+		Replacement: replacement,
+	}, nil
+}
+
+// processSSHFP parses SSHFP rdata, <algorithm> <fingerprint type> <fingerprint>.
+func processSSHFP(rdata []string) (SSHFPValue, error) {
+	if len(rdata) < 3 {
+		return SSHFPValue{}, fmt.Errorf("invalid SSHFP record format: expected <algorithm> <type> <fingerprint>")
+	}
+
+	algorithmNumber, err := parseUint("SSHFP algorithm", rdata[0], 255)
+	if err != nil {
+		return SSHFPValue{}, err
+	}
+	algorithm, ok := sshfpAlgorithms[algorithmNumber]
+	if !ok {
+		return SSHFPValue{}, fmt.Errorf("unsupported SSHFP algorithm %d", algorithmNumber)
+	}
+
+	value := SSHFPValue{Algorithm: algorithm}
+	switch rdata[1] {
+	case "1":
+		fingerprint, err := parseHexField("SSHFP SHA-1 fingerprint", rdata[2:], 40)
+		if err != nil {
+			return SSHFPValue{}, err
+		}
+		value.SHA1Fingerprint = &SSHFPFingerprint{Fingerprint: fingerprint}
+	case "2":
+		fingerprint, err := parseHexField("SSHFP SHA-256 fingerprint", rdata[2:], 64)
+		if err != nil {
+			return SSHFPValue{}, err
+		}
+		value.SHA256Fingerprint = &SSHFPFingerprint{Fingerprint: fingerprint}
+	default:
+		return SSHFPValue{}, fmt.Errorf("unsupported SSHFP fingerprint type %q", rdata[1])
+	}
+
+	return value, nil
+}
+
+// processTLSA parses TLSA rdata, <usage> <selector> <matching type> <data>.
+func processTLSA(rdata []string) (TLSAValue, error) {
+	if len(rdata) < 4 {
+		return TLSAValue{}, fmt.Errorf("invalid TLSA record format: expected <usage> <selector> <matching type> <data>")
+	}
+
+	var fields [3]int
+	for i, field := range []string{"TLSA certificate usage", "TLSA selector", "TLSA matching type"} {
+		n, err := parseUint(field, rdata[i], 255)
+		if err != nil {
+			return TLSAValue{}, err
+		}
+		fields[i] = n
+	}
+
+	usage, ok := tlsaCertificateUsages[fields[0]]
+	if !ok {
+		return TLSAValue{}, fmt.Errorf("unsupported TLSA certificate usage %d", fields[0])
+	}
+	selector, ok := tlsaSelectors[fields[1]]
+	if !ok {
+		return TLSAValue{}, fmt.Errorf("unsupported TLSA selector %d", fields[1])
+	}
+	matchingType, ok := tlsaMatchingTypes[fields[2]]
+	if !ok {
+		return TLSAValue{}, fmt.Errorf("unsupported TLSA matching type %d", fields[2])
+	}
+
+	length := 0
+	switch fields[2] {
+	case 1:
+		length = 64
+	case 2:
+		length = 128
+	}
+	data, err := parseHexField("TLSA certificate association data", rdata[3:], length)
+	if err != nil {
+		return TLSAValue{}, err
+	}
+
+	return TLSAValue{
+		CertificateUsage:           usage,
+		Selector:                   selector,
+		MatchingType:               matchingType,
+		CertificateAssociationData: data,
+	}, nil
+}
+
+// processDS parses DS rdata, <key tag> <algorithm> <digest type> <digest>.
+func processDS(rdata []string) (DSValue, error) {
+	if len(rdata) < 4 {
+		return DSValue{}, fmt.Errorf("invalid DS record format: expected <key tag> <algorithm> <digest type> <digest>")
+	}
+
+	keyTag, err := parseUint("DS key tag", rdata[0], 65535)
+	if err != nil {
+		return DSValue{}, err
+	}
+	algorithmNumber, err := parseUint("DS algorithm", rdata[1], 255)
+	if err != nil {
+		return DSValue{}, err
+	}
+	algorithm, ok := dsKeyAlgorithms[algorithmNumber]
+	if !ok {
+		return DSValue{}, fmt.Errorf("unsupported DS algorithm %d", algorithmNumber)
+	}
+
+	value := DSValue{KeyTag: keyTag, DSKeyAlgorithm: algorithm}
+	switch rdata[2] {
+	case "1":
+		digest, err := parseHexField("DS SHA-1 digest", rdata[3:], 40)
+		if err != nil {
+			return DSValue{}, err
+		}
+		value.SHA1Digest = &DSDigest{Digest: digest}
+	case "2":
+		digest, err := parseHexField("DS SHA-256 digest", rdata[3:], 64)
+		if err != nil {
+			return DSValue{}, err
+		}
+		value.SHA256Digest = &DSDigest{Digest: digest}
+	case "4":
+		digest, err := parseHexField("DS SHA-384 digest", rdata[3:], 96)
+		if err != nil {
+			return DSValue{}, err
+		}
+		value.SHA384Digest = &DSDigest{Digest: digest}
+	default:
+		return DSValue{}, fmt.Errorf("unsupported DS digest type %q", rdata[2])
+	}
+
+	return value, nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	testSHA1Hex   = "0123456789abcdef0123456789abcdef01234567"
+	testSHA256Hex = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+)
+
+func TestProcessNAPTR(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		rdata string
+		want  NAPTRValue
+		err   string
+	}{
+		{"regexp", `100 10 u E2U+sip !^.*$!sip:info@example.com! .`,
+			NAPTRValue{Order: 100, Preference: 10, Flags: "U", Service: "E2U+sip", Regexp: "!^.*$!sip:info@example.com!", Replacement: "."}, ""},
+		{"replacement", `100 10 s SIP+D2U "" _sip._udp`,
+			NAPTRValue{Order: 100, Preference: 10, Flags: "S", Service: "SIP+D2U", Replacement: "_sip._udp.example.com"}, ""},
+		{"too few fields", `100 10 u E2U+sip .`, NAPTRValue{}, "expected"},
+		{"order out of range", `65536 10 u E2U+sip "" .`, NAPTRValue{}, "NAPTR order"},
+		{"bad flags", `100 10 u! E2U+sip "" .`, NAPTRValue{}, "NAPTR flags"},
+		{"regexp and replacement", `100 10 u E2U+sip !a!b! host`, NAPTRValue{}, "cannot both be set"},
+	} {
+		tokens, _, err := tokenizeZoneLine(test.rdata)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rdata []string
+		for _, token := range tokens {
+			rdata = append(rdata, token.Value)
+		}
+
+		got, err := processNAPTR(rdata, "example.com")
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("%s: got %+v, %v, want %+v", test.name, got, err, test.want)
+		}
+	}
+}
+
+func TestProcessSSHFP(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		rdata []string
+		want  SSHFPValue
+		err   string
+	}{
+		{"sha1", []string{"1", "1", strings.ToUpper(testSHA1Hex)},
+			SSHFPValue{Algorithm: "RSA", SHA1Fingerprint: &SSHFPFingerprint{Fingerprint: testSHA1Hex}}, ""},
+		{"sha256 split", []string{"4", "2", testSHA256Hex[:32], testSHA256Hex[32:]},
+			SSHFPValue{Algorithm: "Ed25519", SHA256Fingerprint: &SSHFPFingerprint{Fingerprint: testSHA256Hex}}, ""},
+		{"unknown algorithm", []string{"9", "1", testSHA1Hex}, SSHFPValue{}, "SSHFP algorithm 9"},
+		{"unknown type", []string{"1", "3", testSHA1Hex}, SSHFPValue{}, "fingerprint type"},
+		{"short fingerprint", []string{"1", "1", "abcd"}, SSHFPValue{}, "length"},
+		{"not hex", []string{"1", "1", strings.Repeat("z", 40)}, SSHFPValue{}, "expected hex"},
+	} {
+		got, err := processSSHFP(test.rdata)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, %v, want %+v", test.name, got, err, test.want)
+		}
+	}
+}
+
+func TestProcessTLSA(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		rdata []string
+		want  TLSAValue
+		err   string
+	}{
+		{"sha256", []string{"3", "1", "1", testSHA256Hex},
+			TLSAValue{CertificateUsage: "DomainIssuedCertificate", Selector: "UseSubjectPublicKey", MatchingType: "SHA256", CertificateAssociationData: testSHA256Hex}, ""},
+		{"full certificate", []string{"2", "0", "0", "30", "82ab"},
+			TLSAValue{CertificateUsage: "TrustAnchorAssertion", Selector: "FullCertificate", MatchingType: "NoHash", CertificateAssociationData: "3082ab"}, ""},
+		{"unknown usage", []string{"4", "1", "1", testSHA256Hex}, TLSAValue{}, "certificate usage 4"},
+		{"unknown selector", []string{"3", "2", "1", testSHA256Hex}, TLSAValue{}, "selector 2"},
+		{"wrong digest length", []string{"3", "1", "2", testSHA256Hex}, TLSAValue{}, "length"},
+		{"too few fields", []string{"3", "1", "1"}, TLSAValue{}, "expected"},
+	} {
+		got, err := processTLSA(test.rdata)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("%s: got %+v, %v, want %+v", test.name, got, err, test.want)
+		}
+	}
+}
+
+func TestProcessDS(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		rdata []string
+		want  DSValue
+		err   string
+	}{
+		{"sha256", []string{"12345", "13", "2", testSHA256Hex},
+			DSValue{KeyTag: 12345, DSKeyAlgorithm: "ECDSAP256SHA256", SHA256Digest: &DSDigest{Digest: testSHA256Hex}}, ""},
+		{"sha1", []string{"1", "8", "1", testSHA1Hex},
+			DSValue{KeyTag: 1, DSKeyAlgorithm: "RSASHA256", SHA1Digest: &DSDigest{Digest: testSHA1Hex}}, ""},
+		{"sha384", []string{"1", "14", "4", testSHA256Hex, testSHA256Hex[:32]},
+			DSValue{KeyTag: 1, DSKeyAlgorithm: "ECDSAP384SHA384", SHA384Digest: &DSDigest{Digest: testSHA256Hex + testSHA256Hex[:32]}}, ""},
+		{"key tag out of range", []string{"65536", "13", "2", testSHA256Hex}, DSValue{}, "DS key tag"},
+		{"unknown algorithm", []string{"1", "3", "2", testSHA256Hex}, DSValue{}, "DS algorithm 3"},
+		{"unknown digest type", []string{"1", "13", "3", testSHA256Hex}, DSValue{}, "digest type"},
+	} {
+		got, err := processDS(test.rdata)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, %v, want %+v", test.name, got, err, test.want)
+		}
+	}
+}
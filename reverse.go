@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// isReverseZone reports whether name is under in-addr.arpa or ip6.arpa.
+func isReverseZone(name string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	return strings.HasSuffix(name, ".in-addr.arpa") || strings.HasSuffix(name, ".ip6.arpa")
+}
+
+// reverseZoneFromCIDR returns the reverse zone name for a network, so that
+// -origin 192.0.2.0/24 can be given instead of 2.0.192.in-addr.arpa.
+//
+// IPv4 prefixes on an octet boundary map directly onto in-addr.arpa labels.
+// Longer prefixes use the RFC 2317 classless form, e.g. 192.0.2.64/26 becomes
+// 64/26.2.0.192.in-addr.arpa. IPv6 prefixes must fall on a nibble boundary.
+func reverseZoneFromCIDR(cidr string) (string, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", err
+	}
+	ones, _ := network.Mask.Size()
+
+	if ip4 := network.IP.To4(); ip4 != nil {
+		if ones == 0 {
+			return "", fmt.Errorf("cannot build a reverse zone for %s", cidr)
+		}
+
+		octets := ones / 8
+		var labels []string
+		if ones%8 != 0 {
+			if ones < 24 {
+				return "", fmt.Errorf("classless reverse delegation for %s needs a prefix longer than /24", cidr)
+			}
+			// RFC 2317: <first address>/<prefix length> below the /24 zone
+			labels = append(labels, fmt.Sprintf("%d/%d", ip4[3], ones))
+		}
+		for i := octets - 1; i >= 0; i-- {
+			labels = append(labels, strconv.Itoa(int(ip4[i])))
+		}
+		return strings.Join(append(labels, "in-addr.arpa"), "."), nil
+	}
+
+	if ones == 0 || ones%4 != 0 {
+		return "", fmt.Errorf("IPv6 reverse zone for %s must fall on a nibble boundary", cidr)
+	}
+
+	ip16 := network.IP.To16()
+	nibbles := make([]string, 0, ones/4)
+	for i := ones/4 - 1; i >= 0; i-- {
+		b := ip16[i/2]
+		if i%2 == 0 {
+			b >>= 4
+		}
+		nibbles = append(nibbles, strconv.FormatInt(int64(b&0x0f), 16))
+	}
+	return strings.Join(append(nibbles, "ip6.arpa"), "."), nil
+}
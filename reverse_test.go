@@ -0,0 +1,93 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIsReverseZone(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		want bool
+	}{
+		{"2.0.192.in-addr.arpa", true},
+		{"2.0.192.IN-ADDR.ARPA.", true},
+		{"64/26.2.0.192.in-addr.arpa", true},
+		{"8.b.d.0.1.0.0.2.ip6.arpa", true},
+		{"in-addr.arpa", false},
+		{"example.com", false},
+	} {
+		if got := isReverseZone(test.name); got != test.want {
+			t.Errorf("isReverseZone(%q) = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestReverseZoneFromCIDR(t *testing.T) {
+	for _, test := range []struct {
+		cidr string
+		want string
+		err  string
+	}{
+		{"192.0.2.0/24", "2.0.192.in-addr.arpa", ""},
+		{"10.0.0.0/8", "10.in-addr.arpa", ""},
+		{"172.16.0.0/16", "16.172.in-addr.arpa", ""},
+		{"192.0.2.64/26", "64/26.2.0.192.in-addr.arpa", ""},
+		{"192.0.2.77/26", "64/26.2.0.192.in-addr.arpa", ""},
+		{"2001:db8::/32", "8.b.d.0.1.0.0.2.ip6.arpa", ""},
+		{"2001:db8:1::/48", "1.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa", ""},
+		{"10.0.0.0/12", "", "longer than /24"},
+		{"2001:db8::/30", "", "nibble boundary"},
+		{"0.0.0.0/0", "", "cannot build"},
+		{"192.0.2.0", "", "invalid CIDR"},
+	} {
+		got, err := reverseZoneFromCIDR(test.cidr)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("reverseZoneFromCIDR(%q) error %v, want %q", test.cidr, err, test.err)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("reverseZoneFromCIDR(%q) = %q, %v, want %q", test.cidr, got, err, test.want)
+		}
+	}
+}
+
+// PTR records convert in reverse zones, their targets qualified, and are
+// reported as skipped anywhere else.
+func TestConvertPTRRecords(t *testing.T) {
+	zoneConfig := convertTestZone(t, `$ORIGIN 2.0.192.in-addr.arpa.
+$TTL 3600
+@ IN SOA ns1.example.com. admin.example.com. 1 86400 7200 3600000 3600
+@ IN NS ns1.example.com.
+1 IN PTR host1.example.com.
+1 IN PTR alias
+10 IN PTR www.example.com.
+`, newConversionOptions())
+
+	for _, test := range []struct {
+		name string
+		want []string
+	}{
+		{"1", []string{"alias.2.0.192.in-addr.arpa", "host1.example.com"}},
+		{"10", []string{"www.example.com"}},
+	} {
+		set := findRRSet(t, zoneConfig, "PTR", test.name)
+		if !reflect.DeepEqual(set.PTRRecord.Values, test.want) {
+			t.Errorf("PTR %s: got %v, want %v", test.name, set.PTRRecord.Values, test.want)
+		}
+	}
+
+	opts := newConversionOptions()
+	zoneConfig = convertTestZone(t, testZoneHead+"1 IN PTR host1.example.com.\n", opts)
+	for _, set := range allRRSets(zoneConfig.Spec.Primary) {
+		if set.PTRRecord != nil {
+			t.Errorf("PTR record converted outside a reverse zone: %+v", set.PTRRecord)
+		}
+	}
+	if len(opts.Zone.Skipped) != 1 || opts.Zone.Skipped[0].Type != "PTR" || opts.Zone.Skipped[0].Line != 6 {
+		t.Errorf("got skipped records %+v, want the PTR at line 6", opts.Zone.Skipped)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rules for -group-by
+const (
+	GroupBySubdomain = "subdomain" // one group per delegated subdomain
+	GroupByInclude   = "include"   // one group per $INCLUDE file
+	GroupByTag       = "tag"       // groups named by group= comment tags
+)
+
+func isValidGroupRule(rule string) bool {
+	return rule == "" || rule == GroupBySubdomain || rule == GroupByInclude || rule == GroupByTag
+}
+
+// group=name in a zone file comment, an empty name ends a tagged section
+var groupTagPattern = regexp.MustCompile(`(?:^|\s)group=(\S*)`)
+
+// commentGroupTag returns the group a comment tags its records with.
+func commentGroupTag(comment string) (string, bool) {
+	match := groupTagPattern.FindStringSubmatch(comment)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// Characters XC does not allow in object names
+var groupNamePattern = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// rrSetGroupName turns a subdomain, file or tag into an XC object name, e.g.
+// _tcp.Lab becomes tcp-lab.
+func rrSetGroupName(name string) string {
+	name = groupNamePattern.ReplaceAllString(strings.ToLower(name), "-")
+	name = strings.Trim(name, "-")
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	return name
+}
+
+// groupRRSets moves RR sets out of the default group into named rr_set_groups
+// by rule, one of the GroupBy constants. Records that no rule applies to stay
+// in the default group.
+func groupRRSets(primary *PrimaryZone, records []zoneRecord, mainFile, rule string) {
+	groupOf := make(map[string]string) // RR set key -> group name
+	descriptions := make(map[string]string)
+
+	switch rule {
+	case GroupBySubdomain:
+		// Records at or below a delegation belong to it, glue included
+		var delegations []string
+		for _, set := range primary.DefaultRRSetGroup {
+			if set.NSRecord != nil && set.NSRecord.Name != "" {
+				delegations = append(delegations, strings.ToLower(set.NSRecord.Name))
+			}
+		}
+		for _, set := range primary.DefaultRRSetGroup {
+			rrType, name := rrSetTypeAndName(set)
+			name = strings.ToLower(name)
+			delegation := ""
+			for _, candidate := range delegations {
+				if (name == candidate || strings.HasSuffix(name, "."+candidate)) && len(candidate) > len(delegation) {
+					delegation = candidate
+				}
+			}
+			if delegation != "" {
+				group := rrSetGroupName(delegation)
+				groupOf[rrSetKey(rrType, name)] = group
+				descriptions[group] = "Records of the delegated subdomain " + delegation
+			}
+		}
+	case GroupByInclude, GroupByTag:
+		// An RR set goes with the first of its records
+		for _, record := range records {
+			key := rrSetKey(record.Type, record.Name)
+			if _, seen := groupOf[key]; seen {
+				continue
+			}
+			group := ""
+			if rule == GroupByInclude && record.File != mainFile {
+				base := filepath.Base(record.File)
+				group = rrSetGroupName(strings.TrimSuffix(base, filepath.Ext(base)))
+				descriptions[group] = "Records included from " + base
+			}
+			if rule == GroupByTag {
+				group = rrSetGroupName(record.Group)
+				descriptions[group] = "Records tagged group=" + record.Group
+			}
+			groupOf[key] = group
+		}
+	default:
+		return
+	}
+
+	groups := make(map[string][]DNSRecord)
+	defaultGroup := make([]DNSRecord, 0, len(primary.DefaultRRSetGroup))
+	for _, set := range primary.DefaultRRSetGroup {
+		rrType, name := rrSetTypeAndName(set)
+		group := groupOf[rrSetKey(rrType, name)]
+		if group == "" {
+			defaultGroup = append(defaultGroup, set)
+			continue
+		}
+		groups[group] = append(groups[group], set)
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	primary.DefaultRRSetGroup = defaultGroup
+	primary.RRSetGroup = nil
+	for _, name := range names {
+		group := RRSetGroup{RRSet: groups[name]}
+		group.Metadata.Name = name
+		group.Metadata.Description = descriptions[name]
+		primary.RRSetGroup = append(primary.RRSetGroup, group)
+	}
+	if len(names) > 0 {
+		fmt.Printf("Split %d RR sets into %d rr_set_group(s) by %s\n", countRRSets(primary)-len(defaultGroup), len(names), rule)
+	}
+}
+
+// allRRSets returns the RR sets of the default group followed by those of
+// every named group.
+func allRRSets(primary *PrimaryZone) []DNSRecord {
+	sets := append([]DNSRecord(nil), primary.DefaultRRSetGroup...)
+	for _, group := range primary.RRSetGroup {
+		sets = append(sets, group.RRSet...)
+	}
+	return sets
+}
+
+// countRRSets counts the RR sets of a primary zone over all its groups.
+func countRRSets(primary *PrimaryZone) int {
+	count := len(primary.DefaultRRSetGroup)
+	for _, group := range primary.RRSetGroup {
+		count += len(group.RRSet)
+	}
+	return count
+}
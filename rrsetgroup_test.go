@@ -0,0 +1,116 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// groupContents lists the "type name" of the RR sets of each group of a zone,
+// "" standing for the default group.
+func groupContents(primary *PrimaryZone) map[string][]string {
+	contents := make(map[string][]string)
+	add := func(group string, sets []DNSRecord) {
+		for _, set := range sets {
+			rrType, name := rrSetTypeAndName(set)
+			contents[group] = append(contents[group], rrType+" "+name)
+		}
+		sort.Strings(contents[group])
+	}
+	add("", primary.DefaultRRSetGroup)
+	for _, group := range primary.RRSetGroup {
+		add(group.Metadata.Name, group.RRSet)
+	}
+	return contents
+}
+
+func TestRRSetGroupName(t *testing.T) {
+	for _, test := range []struct{ name, want string }{
+		{"lab", "lab"},
+		{"_tcp.Lab", "tcp-lab"},
+		{"hosts.inc", "hosts-inc"},
+		{"--a__b--", "a-b"},
+	} {
+		if got := rrSetGroupName(test.name); got != test.want {
+			t.Errorf("rrSetGroupName(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGroupRRSetsBySubdomain(t *testing.T) {
+	opts := newConversionOptions()
+	opts.GroupBy = GroupBySubdomain
+	zoneConfig := convertTestZone(t, testZoneHead+`lab IN NS ns.lab
+ns.lab IN A 192.0.2.50
+host.dev.lab IN A 192.0.2.51
+www IN A 192.0.2.10
+`, opts)
+
+	want := map[string][]string{
+		"":    {"A ns1", "A www", "NS "},
+		"lab": {"A host.dev.lab", "A ns.lab", "NS lab"},
+	}
+	if got := groupContents(zoneConfig.Spec.Primary); !reflect.DeepEqual(got, want) {
+		t.Errorf("got groups %v, want %v", got, want)
+	}
+	if description := zoneConfig.Spec.Primary.RRSetGroup[0].Metadata.Description; description != "Records of the delegated subdomain lab" {
+		t.Errorf("got description %q", description)
+	}
+}
+
+// Tags apply from a comment line on, a tag on the record itself wins and an
+// empty tag ends the section. An RR set goes with its first record.
+func TestGroupRRSetsByTag(t *testing.T) {
+	opts := newConversionOptions()
+	opts.GroupBy = GroupByTag
+	zoneConfig := convertTestZone(t, testZoneHead+`; group=web
+www IN A 192.0.2.10
+api IN A 192.0.2.11 ; group=api
+; group=
+mail IN A 192.0.2.25
+www IN A 192.0.2.12
+`, opts)
+
+	want := map[string][]string{
+		"":    {"A mail", "A ns1", "NS "},
+		"web": {"A www"},
+		"api": {"A api"},
+	}
+	if got := groupContents(zoneConfig.Spec.Primary); !reflect.DeepEqual(got, want) {
+		t.Errorf("got groups %v, want %v", got, want)
+	}
+}
+
+func TestGroupRRSetsByInclude(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"test.zone":        testZoneHead + "www IN A 192.0.2.10\n$INCLUDE office-hosts.inc\n",
+		"office-hosts.inc": "printer IN A 192.0.2.60\nwww IN A 192.0.2.61\n",
+	})
+
+	opts := newConversionOptions()
+	opts.GroupBy = GroupByInclude
+	zoneConfig, err := ParseZoneFile(filepath.Join(dir, "test.zone"), "", dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"":             {"A ns1", "A www", "NS "},
+		"office-hosts": {"A printer"},
+	}
+	if got := groupContents(zoneConfig.Spec.Primary); !reflect.DeepEqual(got, want) {
+		t.Errorf("got groups %v, want %v", got, want)
+	}
+}
+
+func TestIsValidGroupRule(t *testing.T) {
+	for _, rule := range []string{"", GroupBySubdomain, GroupByInclude, GroupByTag} {
+		if !isValidGroupRule(rule) {
+			t.Errorf("isValidGroupRule(%q) = false", rule)
+		}
+	}
+	if isValidGroupRule("owner") {
+		t.Error(`isValidGroupRule("owner") = true`)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// sortRRSets puts RR sets into canonical order, the apex first, then by owner
+// and type, and sorts the values of every set. RR sets are built from Go maps,
+// so without this the output of the same zone changes from run to run.
+func sortRRSets(records []DNSRecord) {
+	for i := range records {
+		sortRRSetValues(&records[i])
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		iType, iName := rrSetTypeAndName(records[i])
+		jType, jName := rrSetTypeAndName(records[j])
+		iName, jName = strings.ToLower(iName), strings.ToLower(jName)
+		if iName != jName {
+			// The apex has no name and sorts first
+			return iName < jName
+		}
+		if bindTypeOrder[iType] != bindTypeOrder[jType] {
+			return bindTypeOrder[iType] < bindTypeOrder[jType]
+		}
+		// Sets of the same owner and type only differ in their content
+		iJSON, _ := json.Marshal(records[i])
+		jJSON, _ := json.Marshal(records[j])
+		return string(iJSON) < string(jJSON)
+	})
+}
+
+// ipSortKey orders addresses numerically, 192.0.2.9 before 192.0.2.10.
+func ipSortKey(value string) string {
+	if ip := net.ParseIP(value); ip != nil {
+		return hex.EncodeToString(ip.To16())
+	}
+	return value
+}
+
+// sortRRSetValues sorts the values of one RR set by their fields in
+// presentation order, numbers numerically.
+func sortRRSetValues(record *DNSRecord) {
+	switch {
+	case record.ARecord != nil:
+		values := record.ARecord.Values
+		sort.SliceStable(values, func(i, j int) bool { return ipSortKey(values[i]) < ipSortKey(values[j]) })
+	case record.AAAARecord != nil:
+		values := record.AAAARecord.Values
+		sort.SliceStable(values, func(i, j int) bool { return ipSortKey(values[i]) < ipSortKey(values[j]) })
+	case record.NSRecord != nil:
+		sort.Strings(record.NSRecord.Values)
+	case record.PTRRecord != nil:
+		sort.Strings(record.PTRRecord.Values)
+	case record.TXTRecord != nil:
+		sort.Strings(record.TXTRecord.Values)
+	case record.MXRecord != nil:
+		values := record.MXRecord.Values
+		key := func(value MXValue) string { return fmt.Sprintf("%05d %s", value.Priority, value.Value) }
+		sort.SliceStable(values, func(i, j int) bool { return key(values[i]) < key(values[j]) })
+	case record.SRVRecord != nil:
+		values := record.SRVRecord.Values
+		sort.SliceStable(values, func(i, j int) bool {
+			a, b := values[i], values[j]
+			return fmt.Sprintf("%05d %05d %05d %s", a.Priority, a.Weight, a.Port, a.Target) <
+				fmt.Sprintf("%05d %05d %05d %s", b.Priority, b.Weight, b.Port, b.Target)
+		})
+	case record.CAARecord != nil:
+		values := record.CAARecord.Values
+		key := func(value CAAValue) string { return fmt.Sprintf("%03d %s %s", value.Flags, value.Tag, value.Value) }
+		sort.SliceStable(values, func(i, j int) bool { return key(values[i]) < key(values[j]) })
+	case record.NAPTRRecord != nil:
+		values := record.NAPTRRecord.Values
+		key := func(value NAPTRValue) string {
+			return fmt.Sprintf("%05d %05d %q %q %q %s", value.Order, value.Preference, value.Flags, value.Service, value.Regexp, value.Replacement)
+		}
+		sort.SliceStable(values, func(i, j int) bool { return key(values[i]) < key(values[j]) })
+	case record.SSHFPRecord != nil:
+		values := record.SSHFPRecord.Values
+		key := func(value SSHFPValue) string {
+			if value.SHA1Fingerprint != nil {
+				return value.Algorithm + " 1 " + strings.ToLower(value.SHA1Fingerprint.Fingerprint)
+			}
+			if value.SHA256Fingerprint != nil {
+				return value.Algorithm + " 2 " + strings.ToLower(value.SHA256Fingerprint.Fingerprint)
+			}
+			return value.Algorithm
+		}
+		sort.SliceStable(values, func(i, j int) bool { return key(values[i]) < key(values[j]) })
+	case record.TLSARecord != nil:
+		values := record.TLSARecord.Values
+		key := func(value TLSAValue) string {
+			return value.CertificateUsage + " " + value.Selector + " " + value.MatchingType + " " + strings.ToLower(value.CertificateAssociationData)
+		}
+		sort.SliceStable(values, func(i, j int) bool { return key(values[i]) < key(values[j]) })
+	case record.DSRecord != nil:
+		values := record.DSRecord.Values
+		key := func(value DSValue) string {
+			digest := ""
+			for _, d := range []*DSDigest{value.SHA1Digest, value.SHA256Digest, value.SHA384Digest} {
+				if d != nil {
+					digest = strings.ToLower(d.Digest)
+				}
+			}
+			return fmt.Sprintf("%05d %s %s", value.KeyTag, value.DSKeyAlgorithm, digest)
+		}
+		sort.SliceStable(values, func(i, j int) bool { return key(values[i]) < key(values[j]) })
+	}
+}
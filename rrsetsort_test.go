@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSortRRSets(t *testing.T) {
+	records := []DNSRecord{
+		{TTL: 300, ARecord: &ARecord{Name: "www", Values: []string{"192.0.2.10", "192.0.2.9", "192.0.2.100"}}},
+		{TTL: 300, MXRecord: &MXRecord{Values: []MXValue{{Priority: 20, Value: "b.example.com"}, {Priority: 5, Value: "z.example.com"}, {Priority: 20, Value: "a.example.com"}}}},
+		{TTL: 300, TXTRecord: &TXTRecord{Name: "Mail", Values: []string{"b", "a"}}},
+		{TTL: 300, NSRecord: &NSRecord{Values: []string{"ns2.example.com", "ns1.example.com"}}},
+		{TTL: 300, AAAARecord: &AAAARecord{Name: "www", Values: []string{"2001:db8::10", "2001:db8::9"}}},
+		{TTL: 300, ARecord: &ARecord{Name: "mail", Values: []string{"192.0.2.25"}}},
+	}
+	sortRRSets(records)
+
+	var order []string
+	for _, record := range records {
+		rrType, name := rrSetTypeAndName(record)
+		order = append(order, name+" "+rrType)
+	}
+	want := []string{" NS", " MX", "mail A", "Mail TXT", "www A", "www AAAA"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("got order %q, want %q", order, want)
+	}
+
+	if got := records[4].ARecord.Values; !reflect.DeepEqual(got, []string{"192.0.2.9", "192.0.2.10", "192.0.2.100"}) {
+		t.Errorf("got A values %v, want them in numeric order", got)
+	}
+	if got := records[5].AAAARecord.Values; !reflect.DeepEqual(got, []string{"2001:db8::9", "2001:db8::10"}) {
+		t.Errorf("got AAAA values %v, want them in numeric order", got)
+	}
+	wantMX := []MXValue{{Priority: 5, Value: "z.example.com"}, {Priority: 20, Value: "a.example.com"}, {Priority: 20, Value: "b.example.com"}}
+	if got := records[1].MXRecord.Values; !reflect.DeepEqual(got, wantMX) {
+		t.Errorf("got MX values %+v, want %+v", got, wantMX)
+	}
+	if got := records[0].NSRecord.Values; !reflect.DeepEqual(got, []string{"ns1.example.com", "ns2.example.com"}) {
+		t.Errorf("got NS values %v", got)
+	}
+}
+
+// SRV values sort by priority, weight and port numerically, then target.
+func TestSortRRSetValuesSRV(t *testing.T) {
+	zoneConfig := convertTestZone(t, testZoneHead+`_sip._tcp IN SRV 10 5 5060 b.example.com.
+_sip._tcp IN SRV 10 5 443 c.example.com.
+_sip._tcp IN SRV 2 100 5060 d.example.com.
+`, newConversionOptions())
+
+	var targets []string
+	for _, value := range findRRSet(t, zoneConfig, "SRV", "_sip._tcp").SRVRecord.Values {
+		targets = append(targets, value.Target)
+	}
+	if want := []string{"d.example.com", "c.example.com", "b.example.com"}; !reflect.DeepEqual(targets, want) {
+		t.Errorf("got SRV targets %v, want %v", targets, want)
+	}
+}
+
+// The same zone read twice in a different order converts to the same output.
+func TestConvertDeterministicOutput(t *testing.T) {
+	first := convertTestZone(t, testZoneHead+`www IN A 192.0.2.11
+mail IN MX 10 mx2
+www IN A 192.0.2.10
+mail IN MX 10 mx1
+txt IN TXT "b"
+txt IN TXT "a"
+`, newConversionOptions())
+	second := convertTestZone(t, testZoneHead+`txt IN TXT "a"
+mail IN MX 10 mx1
+www IN A 192.0.2.10
+txt IN TXT "b"
+mail IN MX 10 mx2
+www IN A 192.0.2.11
+`, newConversionOptions())
+
+	firstJSON, _ := json.Marshal(first)
+	secondJSON, _ := json.Marshal(second)
+	if string(firstJSON) != string(secondJSON) {
+		t.Errorf("outputs differ:\n%s\n%s", firstJSON, secondJSON)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// primaryServer is one entry of a zone's masters / primaries list.
+type primaryServer struct {
+	Address string
+	Port    int
+	Key     string
+}
+
+// secondaryZoneConfig builds the XC secondary zone for a BIND slave zone. The
+// primary servers come from the zone's masters (or primaries) list, following
+// named lists, and the TSIG key from the list entries or a matching server
+// statement, resolved against the key statements. Lists and server statements
+// of the zone's view take precedence over top-level ones. The cached zone file is not
+// read.
+func secondaryZoneConfig(conf *NamedConf, zone NamedZone, opts conversionOptions) (*ZoneConfig, error) {
+	var list *namedConfStatement
+	if zone.statement != nil {
+		list = findStatement(zone.statement.Block, "primaries")
+		if list == nil {
+			list = findStatement(zone.statement.Block, "masters")
+		}
+	}
+	if list == nil {
+		return nil, fmt.Errorf("secondary zone has no masters or primaries statement")
+	}
+
+	servers, err := conf.primaryServers(zone, list, 53, make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("secondary zone has an empty masters list")
+	}
+
+	secondary := &SecondaryZone{}
+	for _, server := range servers {
+		if server.Port != 53 {
+			fmt.Printf(ColorRed+"Warning:"+ColorYellow+" %s: primary %s uses port %d, XC transfers from port 53"+ColorReset+"\n", zone.Name, server.Address, server.Port)
+		}
+		if !contains(secondary.PrimaryServers, server.Address) {
+			secondary.PrimaryServers = append(secondary.PrimaryServers, server.Address)
+		}
+
+		key := server.Key
+		if key == "" {
+			key = conf.serverKey(zone, server.Address)
+		}
+		if key == "" {
+			continue
+		}
+		// XC holds a single TSIG key per secondary zone
+		if secondary.TSIGKeyName != "" && secondary.TSIGKeyName != key {
+			return nil, fmt.Errorf("primaries use different TSIG keys (%s, %s), XC supports one key per zone", secondary.TSIGKeyName, key)
+		}
+		secondary.TSIGKeyName = key
+	}
+
+	if err := applyTSIGKey(conf, zone, secondary, opts); err != nil {
+		return nil, err
+	}
+
+	zoneConfig := newZoneConfig()
+	zoneConfig.Metadata.Name = zone.Name
+	zoneConfig.Metadata.Description = "Secondary Zone Converted from BIND Configuration by MC Tool"
+	zoneConfig.Spec.Secondary = secondary
+
+	return zoneConfig, nil
+}
+
+// primaryServers expands a masters / primaries statement:
+//
+//	masters [port N] { address [port N] [key name]; list-name; ... };
+//
+// Entries that are not addresses name a masters / primaries list of the zone's
+// view or the top level.
+func (conf *NamedConf) primaryServers(zone NamedZone, list *namedConfStatement, port int, seen map[string]bool) ([]primaryServer, error) {
+	port, _, err := parsePortKey(list.Args, port)
+	if err != nil {
+		return nil, fmt.Errorf("%s: line %d: %v", list.File, list.Line, err)
+	}
+
+	var servers []primaryServer
+	for _, element := range list.Block {
+		elementPort, key, err := parsePortKey(element.Args, port)
+		if err != nil {
+			return nil, fmt.Errorf("%s: line %d: %v", element.File, element.Line, err)
+		}
+
+		// Addresses may carry a prefix length in an address match list
+		address := strings.SplitN(element.Keyword, "/", 2)[0]
+		if net.ParseIP(address) != nil {
+			servers = append(servers, primaryServer{Address: address, Port: elementPort, Key: key})
+			continue
+		}
+
+		if seen[element.Keyword] {
+			return nil, fmt.Errorf("masters list %q includes itself", element.Keyword)
+		}
+		named := conf.primariesList(zone, element.Keyword)
+		if named == nil {
+			return nil, fmt.Errorf("%s: line %d: unknown masters list or address %q", element.File, element.Line, element.Keyword)
+		}
+		seen[element.Keyword] = true
+		nested, err := conf.primaryServers(zone, named, elementPort, seen)
+		delete(seen, element.Keyword)
+		if err != nil {
+			return nil, err
+		}
+		for _, server := range nested {
+			if server.Key == "" {
+				server.Key = key
+			}
+			servers = append(servers, server)
+		}
+	}
+
+	return servers, nil
+}
+
+// parsePortKey reads the "port N" and "key name" options of a masters list or
+// entry, defaulting to port.
+func parsePortKey(args []string, port int) (int, string, error) {
+	key := ""
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "port":
+			if i+1 >= len(args) {
+				return 0, "", fmt.Errorf("port without a value")
+			}
+			value, err := strconv.Atoi(args[i+1])
+			if err != nil || value < 1 || value > 65535 {
+				return 0, "", fmt.Errorf("invalid port %q", args[i+1])
+			}
+			port = value
+			i++
+		case "key":
+			if i+1 >= len(args) {
+				return 0, "", fmt.Errorf("key without a name")
+			}
+			key = args[i+1]
+			i++
+		}
+	}
+	return port, key, nil
+}
+
+// primariesList returns the masters / primaries list with the given name,
+// looking in the zone's view before the top level.
+func (conf *NamedConf) primariesList(zone NamedZone, name string) *namedConfStatement {
+	for _, statements := range conf.scopes(zone) {
+		for _, statement := range statements {
+			if (statement.Keyword == "masters" || statement.Keyword == "primaries") &&
+				len(statement.Args) > 0 && statement.Args[0] == name {
+				// The list name is not an option of the list itself
+				list := *statement
+				list.Args = statement.Args[1:]
+				return &list
+			}
+		}
+	}
+	return nil
+}
+
+// serverKey returns the first key of a server statement for address, e.g.
+//
+//	server 192.0.2.1 { keys { xfr; }; };
+//
+// A server statement of the zone's view overrides a top-level one, even
+// without keys, as in BIND.
+func (conf *NamedConf) serverKey(zone NamedZone, address string) string {
+	for _, statements := range conf.scopes(zone) {
+		for _, statement := range statements {
+			if statement.Keyword != "server" || len(statement.Args) == 0 {
+				continue
+			}
+			if strings.SplitN(statement.Args[0], "/", 2)[0] != address {
+				continue
+			}
+			if keys := findStatement(statement.Block, "keys"); keys != nil {
+				if len(keys.Block) > 0 {
+					return keys.Block[0].Keyword
+				}
+				if len(keys.Args) > 0 {
+					return keys.Args[0]
+				}
+			}
+			return ""
+		}
+	}
+	return ""
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Masters lists, server statements and keys of a zone's view shadow the
+// top-level ones with the same name or address.
+func TestSecondaryZoneConfigViewScope(t *testing.T) {
+	conf, err := parseNamedConfText(`
+key "xfr" { algorithm hmac-sha256; secret "Z2xvYmFs"; };
+key "global-only" { algorithm hmac-sha256; secret "Z2xvYmFs"; };
+masters upstream { 192.0.2.1; };
+server 192.0.2.2 { keys { global-only; }; };
+
+view "internal" {
+	key "xfr" { algorithm hmac-sha512; secret "dmlldw=="; };
+	masters upstream { 198.51.100.1; };
+	server 198.51.100.1 { keys { xfr; }; };
+	server 192.0.2.2 { };
+	zone "example.com" { type slave; masters { upstream; }; };
+	zone "example.net" { type slave; masters { 192.0.2.2; }; };
+};
+
+zone "example.com" { type slave; masters { upstream; }; };
+zone "example.net" { type slave; masters { 192.0.2.2; }; };
+`, "named.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		view      string
+		zone      string
+		primaries []string
+		key       string
+		algorithm string
+	}{
+		{"internal", "example.com", []string{"198.51.100.1"}, "xfr", "HMAC_SHA512"},
+		{"internal", "example.net", []string{"192.0.2.2"}, "", ""},
+		{"", "example.com", []string{"192.0.2.1"}, "", ""},
+		{"", "example.net", []string{"192.0.2.2"}, "global-only", "HMAC_SHA256"},
+	}
+	for _, test := range tests {
+		var zone NamedZone
+		for _, candidate := range conf.Zones {
+			if candidate.View == test.view && candidate.Name == test.zone {
+				zone = candidate
+			}
+		}
+
+		zoneConfig, err := secondaryZoneConfig(conf, zone, newConversionOptions())
+		if err != nil {
+			t.Errorf("%s/%s: %v", test.view, test.zone, err)
+			continue
+		}
+		secondary := zoneConfig.Spec.Secondary
+		if !reflect.DeepEqual(secondary.PrimaryServers, test.primaries) {
+			t.Errorf("%s/%s: primaries %v, want %v", test.view, test.zone, secondary.PrimaryServers, test.primaries)
+		}
+		if secondary.TSIGKeyName != test.key || secondary.TSIGKeyAlgorithm != test.algorithm {
+			t.Errorf("%s/%s: key %q %q, want %q %q", test.view, test.zone, secondary.TSIGKeyName, secondary.TSIGKeyAlgorithm, test.key, test.algorithm)
+		}
+	}
+}
+
+func TestSecondaryZoneConfigPrimaries(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		conf      string
+		primaries []string
+		key       string
+		err       string
+	}{
+		{"addresses", `zone "example.com" { type slave; masters { 192.0.2.1; 2001:db8::1; }; };`,
+			[]string{"192.0.2.1", "2001:db8::1"}, "", ""},
+		{"primaries keyword", `zone "example.com" { type secondary; primaries { 192.0.2.1; }; };`,
+			[]string{"192.0.2.1"}, "", ""},
+		{"nested lists", `masters inner { 192.0.2.2; 192.0.2.1; };
+masters outer { inner; 192.0.2.3; };
+zone "example.com" { type slave; masters { outer; 192.0.2.1; }; };`,
+			[]string{"192.0.2.2", "192.0.2.1", "192.0.2.3"}, "", ""},
+		{"entry key", `key "xfr" { algorithm hmac-sha256; secret "c2VjcmV0"; };
+zone "example.com" { type slave; masters { 192.0.2.1 key xfr; }; };`,
+			[]string{"192.0.2.1"}, "xfr", ""},
+		{"key on a named list", `key "xfr" { algorithm hmac-sha256; secret "c2VjcmV0"; };
+masters upstream { 192.0.2.1; };
+zone "example.com" { type slave; masters { upstream key xfr; }; };`,
+			[]string{"192.0.2.1"}, "xfr", ""},
+		{"server key", `key "xfr" { algorithm hmac-sha256; secret "c2VjcmV0"; };
+server 192.0.2.1 { keys { xfr; }; };
+zone "example.com" { type slave; masters { 192.0.2.1; }; };`,
+			[]string{"192.0.2.1"}, "xfr", ""},
+		{"no masters", `zone "example.com" { type slave; };`, nil, "", "no masters"},
+		{"empty masters", `zone "example.com" { type slave; masters { }; };`, nil, "", "empty masters"},
+		{"unknown list", `zone "example.com" { type slave; masters { upstream; }; };`, nil, "", "unknown masters list"},
+		{"list including itself", `masters loop { loop; };
+zone "example.com" { type slave; masters { loop; }; };`, nil, "", "includes itself"},
+		{"bad port", `zone "example.com" { type slave; masters port 99999 { 192.0.2.1; }; };`, nil, "", "invalid port"},
+		{"different keys", `key "a" { algorithm hmac-sha256; secret "c2VjcmV0"; };
+key "b" { algorithm hmac-sha256; secret "c2VjcmV0"; };
+zone "example.com" { type slave; masters { 192.0.2.1 key a; 192.0.2.2 key b; }; };`, nil, "", "different TSIG keys"},
+	} {
+		conf, err := parseNamedConfText(test.conf, "named.conf")
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		zoneConfig, err := secondaryZoneConfig(conf, conf.Zones[0], newConversionOptions())
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		secondary := zoneConfig.Spec.Secondary
+		if !reflect.DeepEqual(secondary.PrimaryServers, test.primaries) || secondary.TSIGKeyName != test.key {
+			t.Errorf("%s: got primaries %v key %q, want %v key %q", test.name, secondary.PrimaryServers, secondary.TSIGKeyName, test.primaries, test.key)
+		}
+		if zoneConfig.Metadata.Name != "example.com" || zoneConfig.Spec.Primary != nil {
+			t.Errorf("%s: got zone %+v, want a secondary example.com", test.name, zoneConfig)
+		}
+	}
+}
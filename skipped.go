@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// SkippedRecord describes a zone file entry that did not make it into the XC
+// output, so a migration can be signed off knowing nothing was lost silently.
+type SkippedRecord struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Owner  string `json:"owner,omitempty"`
+	Type   string `json:"type,omitempty"`
+	Reason string `json:"reason"`
+	Text   string `json:"text,omitempty"`
+	// Adjusted entries were converted with a value changed to fit XC
+	Adjusted bool `json:"adjusted,omitempty"`
+}
+
+// recordSkipped prints a warning for an entry that will not be converted and
+// adds it to the skipped records report.
+func (z *zoneState) recordSkipped(skipped SkippedRecord) {
+	z.Skipped = append(z.Skipped, skipped)
+
+	fmt.Printf(ColorRed+"Warning:"+ColorYellow+" %s %s [%s] not converted, %s:"+ColorReset+" %s\n", skipped.location(), skipped.Type, skipped.Owner, skipped.Reason, skipped.Text)
+}
+
+// location is file:line, or only the file for entries without a line.
+func (skipped SkippedRecord) location() string {
+	if skipped.Line > 0 {
+		return fmt.Sprintf("%s:%d", skipped.File, skipped.Line)
+	}
+	return skipped.File
+}
+
+// skipRecord reports a parsed record that could not be converted.
+func (z *zoneState) skipRecord(record zoneRecord, reason string) {
+	z.recordSkipped(SkippedRecord{
+		File:   record.File,
+		Line:   record.Line,
+		Owner:  record.Owner,
+		Type:   record.Type,
+		Reason: reason,
+		Text:   strings.TrimSpace(record.Raw),
+	})
+}
+
+// adjustRecord reports a record that was converted with a changed value.
+func (z *zoneState) adjustRecord(record zoneRecord, reason string) {
+	adjusted := SkippedRecord{
+		File:     record.File,
+		Line:     record.Line,
+		Owner:    record.Owner,
+		Type:     record.Type,
+		Reason:   reason,
+		Text:     strings.TrimSpace(record.Raw),
+		Adjusted: true,
+	}
+	z.Adjusted = append(z.Adjusted, adjusted)
+	fmt.Printf(ColorYellow+"Notice: %s %s [%s] adjusted, %s"+ColorReset+"\n", adjusted.location(), adjusted.Type, adjusted.Owner, adjusted.Reason)
+}
+
+// printSkippedSummary lists the skipped records grouped by record type,
+// followed by the adjusted ones.
+func (z *zoneState) printSkippedSummary() {
+	defer z.printAdjustedSummary()
+	if len(z.Skipped) == 0 {
+		fmt.Println("All records were converted.")
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, skipped := range z.Skipped {
+		rrType := skipped.Type
+		if rrType == "" {
+			rrType = "(unparsed)"
+		}
+		counts[rrType]++
+	}
+
+	types := make([]string, 0, len(counts))
+	for rrType := range counts {
+		types = append(types, rrType)
+	}
+	sort.Strings(types)
+
+	fmt.Printf(ColorYellow+"%d record(s) were not converted:"+ColorReset+"\n", len(z.Skipped))
+	for _, rrType := range types {
+		fmt.Printf("  %-10s %d\n", rrType, counts[rrType])
+	}
+	for _, skipped := range z.Skipped {
+		fmt.Printf("  %s %s %s: %s\n", skipped.location(), skipped.Type, skipped.Owner, skipped.Reason)
+	}
+}
+
+// printAdjustedSummary lists the records converted with a changed value.
+func (z *zoneState) printAdjustedSummary() {
+	if len(z.Adjusted) == 0 {
+		return
+	}
+	fmt.Printf(ColorYellow+"%d value(s) were adjusted to fit XC:"+ColorReset+"\n", len(z.Adjusted))
+	for _, adjusted := range z.Adjusted {
+		fmt.Printf("  %s %s %s: %s\n", adjusted.location(), adjusted.Type, adjusted.Owner, adjusted.Reason)
+	}
+}
+
+// writeSkippedReport writes the skipped records as a JSON array, the adjusted
+// ones marked with "adjusted": true.
+func (z *zoneState) writeSkippedReport(path string) error {
+	report := append([]SkippedRecord{}, z.Skipped...)
+	report = append(report, z.Adjusted...)
+
+	jsonBytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, jsonBytes, 0644)
+}
+
+// reportSkipped prints the skipped records summary and, when path is set,
+// writes the JSON report.
+func (z *zoneState) reportSkipped(path string) {
+	z.printSkippedSummary()
+	if path == "" {
+		return
+	}
+	if err := z.writeSkippedReport(path); err != nil {
+		fmt.Printf("Error writing skipped records report: %v\n", err)
+		return
+	}
+	fmt.Printf("Wrote skipped records report to %s\n", path)
+}
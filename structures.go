@@ -24,15 +24,25 @@ type DNSRecord struct {
 	TTL         int          `json:"ttl,omitempty"`
 	ARecord     *ARecord     `json:"a_record,omitempty"`
 	SRVRecord   *SRVRecord   `json:"srv_record,omitempty"`
-	MXRecord    *[]MXValue   `json:"mx_record,omitempty"`
+	MXRecord    *MXRecord    `json:"mx_record,omitempty"`
 	TXTRecord   *TXTRecord   `json:"txt_record,omitempty"`
 	CNAMERecord *CNAMERecord `json:"cname_record,omitempty"`
 	CAARecord   *CAARecord   `json:"caa_record,omitempty"`
 	NSRecord    *NSRecord    `json:"ns_record,omitempty"`
 	AAAARecord  *AAAARecord  `json:"aaaa_record,omitempty"`
+	PTRRecord   *PTRRecord   `json:"ptr_record,omitempty"`
+	NAPTRRecord *NAPTRRecord `json:"naptr_record,omitempty"`
+	SSHFPRecord *SSHFPRecord `json:"sshfp_record,omitempty"`
+	TLSARecord  *TLSARecord  `json:"tlsa_record,omitempty"`
+	DSRecord    *DSRecord    `json:"ds_record,omitempty"`
 	Description string       `json:"description,omitempty"`
 }
 
+type MXRecord struct {
+	Name   string    `json:"name,omitempty"`
+	Values []MXValue `json:"values"`
+}
+
 // MXValue struct represents an individual MX record's priority and value.
 type MXValue struct {
 	Priority int    `json:"priority"`
@@ -65,9 +75,14 @@ type CNAMERecord struct {
 }
 
 type CAARecord struct {
-	Name  string `json:"name,omitempty"`
-	Flags string `json:"flags,omitempty"`
-	Tag   string `json:"tag,omitempty"`
+	Name   string     `json:"name,omitempty"`
+	Values []CAAValue `json:"values"`
+}
+
+// CAAValue struct represents a single CAA property: flags, tag and value.
+type CAAValue struct {
+	Flags int    `json:"flags"`
+	Tag   string `json:"tag"`
 	Value string `json:"value"`
 }
 
@@ -76,6 +91,75 @@ type NSRecord struct {
 	Values []string `json:"values"`
 }
 
+type PTRRecord struct {
+	Name   string   `json:"name,omitempty"`
+	Values []string `json:"values"`
+}
+
+type NAPTRRecord struct {
+	Name   string       `json:"name,omitempty"`
+	Values []NAPTRValue `json:"values"`
+}
+
+// NAPTRValue struct represents a single NAPTR rule (RFC 3403).
+type NAPTRValue struct {
+	Order       int    `json:"order"`
+	Preference  int    `json:"preference"`
+	Flags       string `json:"flags"`
+	Service     string `json:"service"`
+	Regexp      string `json:"regexp"`
+	Replacement string `json:"replacement"`
+}
+
+type SSHFPRecord struct {
+	Name   string       `json:"name,omitempty"`
+	Values []SSHFPValue `json:"values"`
+}
+
+// SSHFPValue struct represents a host key fingerprint (RFC 4255), only one of
+// the fingerprint fields is set depending on the fingerprint type.
+type SSHFPValue struct {
+	Algorithm         string            `json:"algorithm"`
+	SHA1Fingerprint   *SSHFPFingerprint `json:"sha1_fingerprint,omitempty"`
+	SHA256Fingerprint *SSHFPFingerprint `json:"sha256_fingerprint,omitempty"`
+}
+
+type SSHFPFingerprint struct {
+	Fingerprint string `json:"fingerprint"`
+}
+
+type TLSARecord struct {
+	Name   string      `json:"name,omitempty"`
+	Values []TLSAValue `json:"values"`
+}
+
+// TLSAValue struct represents a certificate association (RFC 6698) using the XC enum names.
+type TLSAValue struct {
+	CertificateUsage           string `json:"certificate_usage"`
+	Selector                   string `json:"selector"`
+	MatchingType               string `json:"matching_type"`
+	CertificateAssociationData string `json:"certificate_association_data"`
+}
+
+type DSRecord struct {
+	Name   string    `json:"name,omitempty"`
+	Values []DSValue `json:"values"`
+}
+
+// DSValue struct represents a delegation signer (RFC 4034), only one of the
+// digest fields is set depending on the digest type.
+type DSValue struct {
+	KeyTag         int       `json:"key_tag"`
+	DSKeyAlgorithm string    `json:"ds_key_algorithm"`
+	SHA1Digest     *DSDigest `json:"sha1_digest,omitempty"`
+	SHA256Digest   *DSDigest `json:"sha256_digest,omitempty"`
+	SHA384Digest   *DSDigest `json:"sha384_digest,omitempty"`
+}
+
+type DSDigest struct {
+	Digest string `json:"digest"`
+}
+
 type TXTRecord struct {
 	Name   string   `json:"name,omitempty"`
 	Values []string `json:"values"`
@@ -104,15 +188,47 @@ type ZoneConfig struct {
 		Disable     bool              `json:"disable"`
 	} `json:"metadata"`
 	Spec struct {
-		Primary struct {
-			SOAParameters     SOAParameters `json:"soa_parameters"` // Renamed to match the provided format
-			DefaultRRSetGroup []DNSRecord   `json:"default_rr_set_group"`
-			DNSSECMode        DNSSECMode    `json:"dnssec_mode"` // Added for DNSSEC configuration
-			//AllowHTTPLoadBalancerManagedRecords bool          `json:"allow_http_lb_managed_records"`
-		} `json:"primary"`
+		Primary   *PrimaryZone   `json:"primary,omitempty"`
+		Secondary *SecondaryZone `json:"secondary,omitempty"`
 	} `json:"spec"`
 }
 
+// PrimaryZone is a zone served from the records converted out of the zone file.
+type PrimaryZone struct {
+	SOAParameters     SOAParameters `json:"soa_parameters"` // Renamed to match the provided format
+	DefaultRRSetGroup []DNSRecord   `json:"default_rr_set_group"`
+	RRSetGroup        []RRSetGroup  `json:"rr_set_group,omitempty"` // Named groups, see -group-by
+	DNSSECMode        DNSSECMode    `json:"dnssec_mode"`            // Added for DNSSEC configuration
+	//AllowHTTPLoadBalancerManagedRecords bool          `json:"allow_http_lb_managed_records"`
+}
+
+// RRSetGroup is a named group of RR sets kept apart from the default group.
+type RRSetGroup struct {
+	Metadata struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+	} `json:"metadata"`
+	RRSet []DNSRecord `json:"rr_set"`
+}
+
+// SecondaryZone is a zone XC transfers from the BIND primaries instead of
+// holding its records.
+type SecondaryZone struct {
+	PrimaryServers   []string    `json:"primary_servers"`
+	TSIGKeyName      string      `json:"tsig_key_name,omitempty"`
+	TSIGKeyAlgorithm string      `json:"tsig_key_algorithm,omitempty"`
+	TSIGKeyValue     *SecretType `json:"tsig_key_value,omitempty"`
+}
+
+// SecretType holds a secret such as a TSIG key.
+type SecretType struct {
+	ClearSecretInfo *ClearSecretInfo `json:"clear_secret_info,omitempty"`
+}
+
+type ClearSecretInfo struct {
+	URL string `json:"url"`
+}
+
 type DisabledType struct{}
 
 // DNSSECMode adjusted to use the DisabledType for clarity
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// orderedValue is a JSON value that keeps the order of object fields, so the
+// Terraform output follows the field order of structures.go.
+type orderedValue struct {
+	Fields []orderedField // objects
+	Items  []orderedValue // arrays
+	Scalar interface{}    // strings, json.Number, bools and nil
+	Kind   byte           // '{', '[' or 0 for scalars
+}
+
+type orderedField struct {
+	Name  string
+	Value orderedValue
+}
+
+// decodeOrdered reads the next JSON value from a decoder that uses numbers.
+func decodeOrdered(decoder *json.Decoder) (orderedValue, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return orderedValue{}, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		value := orderedValue{Kind: '{'}
+		for decoder.More() {
+			name, err := decoder.Token()
+			if err != nil {
+				return value, err
+			}
+			fieldValue, err := decodeOrdered(decoder)
+			if err != nil {
+				return value, err
+			}
+			value.Fields = append(value.Fields, orderedField{Name: name.(string), Value: fieldValue})
+		}
+		_, err = decoder.Token()
+		return value, err
+	case json.Delim('['):
+		value := orderedValue{Kind: '['}
+		for decoder.More() {
+			item, err := decodeOrdered(decoder)
+			if err != nil {
+				return value, err
+			}
+			value.Items = append(value.Items, item)
+		}
+		_, err = decoder.Token()
+		return value, err
+	}
+	return orderedValue{Scalar: token}, nil
+}
+
+// Attributes named differently by the volterra provider than by the API JSON,
+// keyed by the enclosing block and its parent
+var terraformAttributeNames = map[string]map[string]string{
+	"mx_record.values": {"value": "domain"},
+}
+
+// Characters Terraform does not allow in resource names
+var terraformNamePattern = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// terraformResourceName turns a zone name into a resource name, e.g.
+// example.com becomes example_com.
+func terraformResourceName(zoneName string) string {
+	name := terraformNamePattern.ReplaceAllString(strings.TrimSuffix(zoneName, "."), "_")
+	if name == "" || !unicode.IsLetter(rune(name[0])) && name[0] != '_' {
+		name = "zone_" + name
+	}
+	return name
+}
+
+// quoteHCL writes a string as a quoted HCL template literal, escaping the
+// ${ and %{ template sequences as well.
+func quoteHCL(value string) string {
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	for i, r := range value {
+		switch {
+		case r == '"' || r == '\\':
+			quoted.WriteByte('\\')
+			quoted.WriteRune(r)
+		case r == '\n':
+			quoted.WriteString(`\n`)
+		case r == '\r':
+			quoted.WriteString(`\r`)
+		case r == '\t':
+			quoted.WriteString(`\t`)
+		case (r == '$' || r == '%') && strings.HasPrefix(value[i+1:], "{"):
+			quoted.WriteRune(r)
+			quoted.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&quoted, `\u%04x`, r)
+		default:
+			quoted.WriteRune(r)
+		}
+	}
+	quoted.WriteByte('"')
+	return quoted.String()
+}
+
+// hclScalar renders a JSON scalar as an HCL literal.
+func hclScalar(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return quoteHCL(value)
+	case json.Number:
+		return value.String()
+	case bool:
+		return fmt.Sprint(value)
+	}
+	return "null"
+}
+
+// hclLine is one line of a block body, attributes of consecutive lines are
+// aligned on their "=" like terraform fmt does.
+type hclLine struct {
+	Name  string // attribute name, empty for other lines
+	Value string
+	Text  string
+}
+
+// hclBlockLines renders the fields of an object as the body of a block.
+// Objects become nested blocks, arrays of objects repeated blocks, empty
+// objects (the XC way of choosing a oneof) "= true" and nulls are left out.
+func hclBlockLines(path string, value orderedValue) []hclLine {
+	block := path
+	if parts := strings.Split(path, "."); len(parts) > 2 {
+		block = strings.Join(parts[len(parts)-2:], ".")
+	}
+
+	var lines []hclLine
+	for _, field := range value.Fields {
+		name := field.Name
+		if renamed, ok := terraformAttributeNames[block][name]; ok {
+			name = renamed
+		}
+		fieldPath := field.Name
+		if path != "" {
+			fieldPath = path + "." + field.Name
+		}
+
+		switch field.Value.Kind {
+		case '{':
+			if len(field.Value.Fields) == 0 {
+				lines = append(lines, hclLine{Name: name, Value: "true"})
+				continue
+			}
+			lines = append(lines, hclBlock(name, fieldPath, field.Value)...)
+		case '[':
+			if len(field.Value.Items) > 0 && field.Value.Items[0].Kind == '{' {
+				for _, item := range field.Value.Items {
+					lines = append(lines, hclBlock(name, fieldPath, item)...)
+				}
+				continue
+			}
+			items := make([]string, 0, len(field.Value.Items))
+			for _, item := range field.Value.Items {
+				items = append(items, hclScalar(item.Scalar))
+			}
+			lines = append(lines, hclLine{Name: name, Value: "[" + strings.Join(items, ", ") + "]"})
+		default:
+			if field.Value.Scalar == nil {
+				continue
+			}
+			lines = append(lines, hclLine{Name: name, Value: hclScalar(field.Value.Scalar)})
+		}
+	}
+	return lines
+}
+
+// hclBlock renders a nested block, separated from the lines before it by an
+// empty line.
+func hclBlock(name, path string, value orderedValue) []hclLine {
+	lines := []hclLine{{Text: ""}, {Text: name + " {"}}
+	for _, line := range hclBlockLines(path, value) {
+		if line.Text != "" || line.Name != "" {
+			line.Text = "  " + line.Text
+		}
+		lines = append(lines, line)
+	}
+	return append(lines, hclLine{Text: "}"})
+}
+
+// writeHCLLines writes block lines, aligning runs of attributes.
+func writeHCLLines(w io.Writer, lines []hclLine, indent string) {
+	for i := 0; i < len(lines); {
+		if lines[i].Name == "" {
+			// No empty line right after an opening brace
+			if lines[i].Text != "" || (i > 0 && !strings.HasSuffix(lines[i-1].Text, "{")) {
+				if strings.TrimSpace(lines[i].Text) == "" {
+					fmt.Fprintln(w)
+				} else {
+					fmt.Fprintln(w, indent+lines[i].Text)
+				}
+			}
+			i++
+			continue
+		}
+
+		end, width := i, 0
+		for ; end < len(lines) && lines[end].Name != ""; end++ {
+			if len(lines[end].Name) > width {
+				width = len(lines[end].Name)
+			}
+		}
+		for ; i < end; i++ {
+			fmt.Fprintf(w, "%s%s%-*s = %s\n", indent, lines[i].Text, width, lines[i].Name, lines[i].Value)
+		}
+	}
+}
+
+// renderTerraform renders a zone as a volterra_dns_zone resource, in
+// defaultNamespace unless the zone names its own.
+func renderTerraform(zoneConfig *ZoneConfig, defaultNamespace string) ([]byte, error) {
+	specJSON, err := json.Marshal(zoneConfig.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling to JSON: %v", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(specJSON))
+	decoder.UseNumber()
+	specValue, err := decodeOrdered(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := zoneConfig.Metadata.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	lines := []hclLine{
+		{Name: "name", Value: quoteHCL(zoneConfig.Metadata.Name)},
+		{Name: "namespace", Value: quoteHCL(namespace)},
+	}
+	if zoneConfig.Metadata.Description != "" {
+		lines = append(lines, hclLine{Name: "description", Value: quoteHCL(zoneConfig.Metadata.Description)})
+	}
+	for _, attribute := range []struct {
+		name   string
+		values map[string]string
+	}{
+		{"labels", zoneConfig.Metadata.Labels},
+		{"annotations", zoneConfig.Metadata.Annotations},
+	} {
+		if len(attribute.values) == 0 {
+			continue
+		}
+		keys := make([]string, 0, len(attribute.values))
+		for key := range attribute.values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		lines = append(lines, hclLine{Text: ""}, hclLine{Text: attribute.name + " = {"})
+		for _, key := range keys {
+			lines = append(lines, hclLine{Text: "  ", Name: quoteHCL(key), Value: quoteHCL(attribute.values[key])})
+		}
+		lines = append(lines, hclLine{Text: "}"})
+	}
+	lines = append(lines, hclBlockLines("", specValue)...)
+
+	var hcl bytes.Buffer
+	fmt.Fprintf(&hcl, "resource \"volterra_dns_zone\" %s {\n", quoteHCL(terraformResourceName(zoneConfig.Metadata.Name)))
+	writeHCLLines(&hcl, lines, "  ")
+	fmt.Fprintln(&hcl, "}")
+	return hcl.Bytes(), nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTerraformResourceName(t *testing.T) {
+	for _, test := range []struct{ zone, want string }{
+		{"example.com", "example_com"},
+		{"example.com.", "example_com"},
+		{"2.0.192.in-addr.arpa", "zone_2_0_192_in-addr_arpa"},
+		{"64/26.2.0.192.in-addr.arpa", "zone_64_26_2_0_192_in-addr_arpa"},
+		{"_tcp.example.com", "_tcp_example_com"},
+	} {
+		if got := terraformResourceName(test.zone); got != test.want {
+			t.Errorf("terraformResourceName(%q) = %q, want %q", test.zone, got, test.want)
+		}
+	}
+}
+
+func TestQuoteHCL(t *testing.T) {
+	for _, test := range []struct{ value, want string }{
+		{"plain", `"plain"`},
+		{`say "hi" \o/`, `"say \"hi\" \\o/"`},
+		{"${var} %{if} $5 %d", `"$${var} %%{if} $5 %d"`},
+		{"tab\tnew\nline\x01", `"tab\tnew\nline\u0001"`},
+	} {
+		if got := quoteHCL(test.value); got != test.want {
+			t.Errorf("quoteHCL(%q) = %s, want %s", test.value, got, test.want)
+		}
+	}
+}
+
+func TestRenderTerraform(t *testing.T) {
+	zoneConfig := testPrimaryZone("example.com", "192.0.2.1")
+	zoneConfig.Metadata.Labels["team"] = "dns"
+	zoneConfig.Spec.Primary.DefaultRRSetGroup = append(zoneConfig.Spec.Primary.DefaultRRSetGroup,
+		DNSRecord{TTL: 300, MXRecord: &MXRecord{Values: []MXValue{{Priority: 10, Value: "mail.example.com"}}}},
+		DNSRecord{TTL: 300, TXTRecord: &TXTRecord{Name: "txt", Values: []string{"v=spf1 ${x}"}}})
+
+	got, err := renderTerraform(zoneConfig, "system")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `resource "volterra_dns_zone" "example_com" {
+  name        = "example.com"
+  namespace   = "system"
+  description = "Zone Converted from BIND Zone File by MC Tool"
+
+  labels = {
+    "team" = "dns"
+  }
+
+  primary {
+    soa_parameters {
+      refresh      = 86400
+      retry        = 7200
+      expire       = 3600000
+      negative_ttl = 1801
+      ttl          = 3600
+    }
+
+    default_rr_set_group {
+      ttl = 3600
+
+      a_record {
+        name   = "www"
+        values = ["192.0.2.1"]
+      }
+    }
+
+    default_rr_set_group {
+      ttl = 300
+
+      mx_record {
+        values {
+          priority = 10
+          domain   = "mail.example.com"
+        }
+      }
+    }
+
+    default_rr_set_group {
+      ttl = 300
+
+      txt_record {
+        name   = "txt"
+        values = ["v=spf1 $${x}"]
+      }
+    }
+
+    dnssec_mode {
+      disable = true
+    }
+  }
+}
+`
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// A namespace of the zone itself wins over the default
+	zoneConfig.Metadata.Namespace = "shared"
+	got, err = renderTerraform(zoneConfig, "system")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), `namespace   = "shared"`) {
+		t.Errorf("zone namespace not used:\n%s", got)
+	}
+}
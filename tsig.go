@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// NamedKey is a TSIG key statement of named.conf:
+//
+//	key "xfr" { algorithm hmac-sha256; secret "..."; };
+type NamedKey struct {
+	Name      string
+	Algorithm string
+	Secret    string
+}
+
+// XC TSIG algorithm enum values by BIND algorithm name
+var tsigAlgorithms = map[string]string{
+	"hmac-md5":                 "HMAC_MD5",
+	"hmac-md5.sig-alg.reg.int": "HMAC_MD5",
+	"hmac-sha1":                "HMAC_SHA1",
+	"hmac-sha224":              "HMAC_SHA224",
+	"hmac-sha256":              "HMAC_SHA256",
+	"hmac-sha384":              "HMAC_SHA384",
+	"hmac-sha512":              "HMAC_SHA512",
+}
+
+// ZoneSecret is a TSIG secret taken out of a zone output when secrets are
+// redacted, so it can be stored apart from the configuration.
+type ZoneSecret struct {
+	Zone      string `json:"zone"`
+	View      string `json:"view,omitempty"`
+	KeyName   string `json:"tsig_key_name"`
+	Algorithm string `json:"tsig_key_algorithm"`
+	Secret    string `json:"secret"`
+}
+
+// newNamedKey reads the algorithm and secret of a key statement.
+func newNamedKey(statement *namedConfStatement) NamedKey {
+	key := NamedKey{}
+	if len(statement.Args) > 0 {
+		key.Name = statement.Args[0]
+	}
+	if algorithm := findStatement(statement.Block, "algorithm"); algorithm != nil && len(algorithm.Args) > 0 {
+		key.Algorithm = strings.ToLower(algorithm.Args[0])
+	}
+	if secret := findStatement(statement.Block, "secret"); secret != nil && len(secret.Args) > 0 {
+		key.Secret = secret.Args[0]
+	}
+	return key
+}
+
+// applyTSIGKey fills in the TSIG algorithm and secret of a secondary zone
+// from the key statement its primaries reference. Keys of the zone's view
+// take precedence over global ones. With opts.SecretsFile the secret is
+// collected in opts.Run instead of embedded.
+func applyTSIGKey(conf *NamedConf, zone NamedZone, secondary *SecondaryZone, opts conversionOptions) error {
+	if secondary.TSIGKeyName == "" {
+		return nil
+	}
+
+	key, exists := zone.keys[secondary.TSIGKeyName]
+	if !exists {
+		key, exists = conf.Keys[secondary.TSIGKeyName]
+	}
+	if !exists {
+		return fmt.Errorf("TSIG key %q is not defined", secondary.TSIGKeyName)
+	}
+	algorithm, exists := tsigAlgorithms[key.Algorithm]
+	if !exists {
+		return fmt.Errorf("TSIG key %q uses algorithm %q, which XC does not support", key.Name, key.Algorithm)
+	}
+	if key.Secret == "" {
+		return fmt.Errorf("TSIG key %q has no secret", key.Name)
+	}
+	if _, err := base64.StdEncoding.DecodeString(key.Secret); err != nil {
+		return fmt.Errorf("TSIG key %q has a secret that is not valid base64", key.Name)
+	}
+
+	secondary.TSIGKeyAlgorithm = algorithm
+
+	if opts.SecretsFile != "" {
+		opts.Run.Secrets = append(opts.Run.Secrets, ZoneSecret{Zone: zone.Name, View: zone.View, KeyName: key.Name, Algorithm: algorithm, Secret: key.Secret})
+		return nil
+	}
+
+	// Clear secrets are passed to XC as a base64 "string:///" URL
+	secondary.TSIGKeyValue = &SecretType{
+		ClearSecretInfo: &ClearSecretInfo{URL: "string:///" + base64.StdEncoding.EncodeToString([]byte(key.Secret))},
+	}
+	return nil
+}
+
+// writeSecretsFile writes the redacted TSIG secrets as a JSON array.
+func writeSecretsFile(path string, secrets []ZoneSecret) error {
+	if secrets == nil {
+		secrets = []ZoneSecret{}
+	}
+
+	jsonBytes, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling secrets: %v", err)
+	}
+
+	// Only the owner may read the secrets
+	if err := os.WriteFile(path, jsonBytes, 0600); err != nil {
+		return fmt.Errorf("error writing secrets file: %v", err)
+	}
+	return nil
+}
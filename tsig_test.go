@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testTSIGConf = `key "xfr" { algorithm HMAC-SHA256; secret "c2VjcmV0"; };
+key "md5" { algorithm hmac-md5.sig-alg.reg.int; secret "c2VjcmV0"; };
+key "gost" { algorithm hmac-gost; secret "c2VjcmV0"; };
+key "empty" { algorithm hmac-sha256; };
+key "bad" { algorithm hmac-sha256; secret "not base64!"; };
+zone "example.com" { type slave; masters { 192.0.2.1; }; };
+`
+
+func TestApplyTSIGKey(t *testing.T) {
+	conf, err := parseNamedConfText(testTSIGConf, "named.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zone := conf.Zones[0]
+
+	for _, test := range []struct {
+		key       string
+		algorithm string
+		err       string
+	}{
+		{"xfr", "HMAC_SHA256", ""},
+		{"md5", "HMAC_MD5", ""},
+		{"gost", "", "which XC does not support"},
+		{"empty", "", "has no secret"},
+		{"bad", "", "not valid base64"},
+		{"missing", "", "is not defined"},
+	} {
+		secondary := &SecondaryZone{TSIGKeyName: test.key}
+		err := applyTSIGKey(conf, zone, secondary, newConversionOptions())
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: got error %v, want %q", test.key, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.key, err)
+			continue
+		}
+		if secondary.TSIGKeyAlgorithm != test.algorithm {
+			t.Errorf("%s: got algorithm %s, want %s", test.key, secondary.TSIGKeyAlgorithm, test.algorithm)
+		}
+		want := "string:///" + base64.StdEncoding.EncodeToString([]byte("c2VjcmV0"))
+		if secondary.TSIGKeyValue == nil || secondary.TSIGKeyValue.ClearSecretInfo == nil || secondary.TSIGKeyValue.ClearSecretInfo.URL != want {
+			t.Errorf("%s: got secret %+v, want %s", test.key, secondary.TSIGKeyValue, want)
+		}
+	}
+
+	// Without a key name there is nothing to look up
+	if err := applyTSIGKey(conf, zone, &SecondaryZone{}, newConversionOptions()); err != nil {
+		t.Errorf("no key: %v", err)
+	}
+}
+
+// With a secrets file the secret is collected instead of written into the
+// zone, and the file is only readable by its owner.
+func TestApplyTSIGKeyRedacted(t *testing.T) {
+	conf, err := parseNamedConfText(testTSIGConf, "named.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := newConversionOptions()
+	opts.SecretsFile = filepath.Join(t.TempDir(), "secrets.json")
+	secondary := &SecondaryZone{TSIGKeyName: "xfr"}
+	if err := applyTSIGKey(conf, conf.Zones[0], secondary, opts); err != nil {
+		t.Fatal(err)
+	}
+	if secondary.TSIGKeyValue != nil || secondary.TSIGKeyAlgorithm != "HMAC_SHA256" {
+		t.Errorf("got secondary %+v, want the algorithm without the secret", secondary)
+	}
+
+	want := ZoneSecret{Zone: "example.com", KeyName: "xfr", Algorithm: "HMAC_SHA256", Secret: "c2VjcmV0"}
+	if len(opts.Run.Secrets) != 1 || opts.Run.Secrets[0] != want {
+		t.Fatalf("got secrets %+v, want %+v", opts.Run.Secrets, want)
+	}
+
+	if err := writeSecretsFile(opts.SecretsFile, opts.Run.Secrets); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(opts.SecretsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("secrets file has mode %v, want 0600", info.Mode().Perm())
+	}
+	data, err := os.ReadFile(opts.SecretsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var secrets []ZoneSecret
+	if err := json.Unmarshal(data, &secrets); err != nil || len(secrets) != 1 || secrets[0] != want {
+		t.Errorf("got secrets file %s, %v", data, err)
+	}
+}
+
+func TestWriteSecretsFileEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	if err := writeSecretsFile(path, nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "[]" {
+		t.Errorf("got %q, %v, want an empty JSON array", data, err)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TTL conflict policies. XC carries one TTL per RR set, so when records of the
+// same owner and type were written with different TTLs one has to be picked:
+//
+//	min   - use the lowest TTL (default, never caches longer than the zone asked for)
+//	max   - use the highest TTL
+//	error - refuse to convert the zone and list every disagreeing record
+const (
+	TTLPolicyMin   = "min"
+	TTLPolicyMax   = "max"
+	TTLPolicyError = "error"
+)
+
+func isValidTTLPolicy(policy string) bool {
+	return policy == TTLPolicyMin || policy == TTLPolicyMax || policy == TTLPolicyError
+}
+
+// rrSetTTLs tracks the TTL of every RR set while a zone is accumulated.
+type rrSetTTLs struct {
+	policy    string
+	ttls      map[string]int
+	sources   map[string]zoneRecord // first record seen for each set
+	conflicts []string
+}
+
+func newRRSetTTLs(policy string) *rrSetTTLs {
+	return &rrSetTTLs{
+		policy:  policy,
+		ttls:    make(map[string]int),
+		sources: make(map[string]zoneRecord),
+	}
+}
+
+func rrSetKey(rrType, name string) string {
+	return rrType + "|" + strings.ToLower(name)
+}
+
+// add records the TTL of a converted record against its RR set.
+func (s *rrSetTTLs) add(record zoneRecord) {
+	key := rrSetKey(record.Type, record.Name)
+
+	current, exists := s.ttls[key]
+	if !exists {
+		s.ttls[key] = record.TTL
+		s.sources[key] = record
+		return
+	}
+	if current == record.TTL {
+		return
+	}
+
+	first := s.sources[key]
+	conflict := fmt.Sprintf("%s %s: TTL %d at %s:%d disagrees with TTL %d at %s:%d",
+		record.Type, record.Owner, record.TTL, record.File, record.Line, first.TTL, first.File, first.Line)
+
+	switch s.policy {
+	case TTLPolicyError:
+		s.conflicts = append(s.conflicts, conflict)
+	case TTLPolicyMax:
+		if record.TTL > current {
+			s.ttls[key] = record.TTL
+		}
+		fmt.Printf(ColorRed+"Warning:"+ColorYellow+" %s, using the highest TTL %d"+ColorReset+"\n", conflict, s.ttls[key])
+	default:
+		if record.TTL < current {
+			s.ttls[key] = record.TTL
+		}
+		fmt.Printf(ColorRed+"Warning:"+ColorYellow+" %s, using the lowest TTL %d"+ColorReset+"\n", conflict, s.ttls[key])
+	}
+}
+
+// get returns the TTL chosen for an RR set, or fallback if no record of the
+// set carried one.
+func (s *rrSetTTLs) get(rrType, name string, fallback int) int {
+	if ttl, exists := s.ttls[rrSetKey(rrType, name)]; exists {
+		return ttl
+	}
+	return fallback
+}
+
+// err returns the TTL conflicts found under the error policy.
+func (s *rrSetTTLs) err() error {
+	if len(s.conflicts) == 0 {
+		return nil
+	}
+	return fmt.Errorf("records in the same RR set have different TTLs (-ttl-policy %s):\n  %s", s.policy, strings.Join(s.conflicts, "\n  "))
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Each RR set keeps the TTL its records were written with, not a fixed one.
+func TestConvertKeepsRecordTTLs(t *testing.T) {
+	zoneConfig := convertTestZone(t, `$ORIGIN example.com.
+$TTL 7200
+@ IN SOA ns1.example.com. admin.example.com. 1 86400 7200 3600000 3600
+@ 172800 IN NS ns1.example.com.
+@ 60 IN A 192.0.2.1
+ns1 IN A 192.0.2.53
+failover 30 IN A 192.0.2.80
+`, newConversionOptions())
+
+	for _, test := range []struct {
+		rrType, name string
+		want         int
+	}{
+		{"NS", "", 172800},
+		{"A", "", 60},
+		{"A", "ns1", 7200},
+		{"A", "failover", 30},
+	} {
+		if set := findRRSet(t, zoneConfig, test.rrType, test.name); set.TTL != test.want {
+			t.Errorf("%s %q: got TTL %d, want %d", test.rrType, test.name, set.TTL, test.want)
+		}
+	}
+}
+
+func TestRRSetTTLPolicies(t *testing.T) {
+	records := []zoneRecord{
+		{File: "test.zone", Line: 1, Owner: "www.example.com", Name: "www", Type: "A", TTL: 300},
+		{File: "test.zone", Line: 2, Owner: "www.example.com", Name: "WWW", Type: "A", TTL: 60},
+		{File: "test.zone", Line: 3, Owner: "www.example.com", Name: "www", Type: "A", TTL: 600},
+		{File: "test.zone", Line: 4, Owner: "www.example.com", Name: "www", Type: "TXT", TTL: 900},
+	}
+
+	for _, test := range []struct {
+		policy string
+		want   int
+	}{
+		{TTLPolicyMin, 60},
+		{TTLPolicyMax, 600},
+	} {
+		ttls := newRRSetTTLs(test.policy)
+		for _, record := range records {
+			ttls.add(record)
+		}
+		if got := ttls.get("A", "www", 0); got != test.want {
+			t.Errorf("%s: got A TTL %d, want %d", test.policy, got, test.want)
+		}
+		if got := ttls.get("TXT", "www", 0); got != 900 {
+			t.Errorf("%s: got TXT TTL %d, want 900", test.policy, got)
+		}
+		if got := ttls.get("A", "mail", 1234); got != 1234 {
+			t.Errorf("%s: got TTL %d for an unknown set, want the fallback", test.policy, got)
+		}
+		if err := ttls.err(); err != nil {
+			t.Errorf("%s: %v", test.policy, err)
+		}
+	}
+
+	ttls := newRRSetTTLs(TTLPolicyError)
+	for _, record := range records {
+		ttls.add(record)
+	}
+	err := ttls.err()
+	if err == nil {
+		t.Fatal("error policy accepted disagreeing TTLs")
+	}
+	for _, want := range []string{"test.zone:2", "test.zone:3"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not name %s", err, want)
+		}
+	}
+}
+
+// Under the error policy the conversion fails instead of picking a TTL.
+func TestConvertTTLPolicyError(t *testing.T) {
+	opts := newConversionOptions()
+	opts.TTLPolicy = TTLPolicyError
+	reader := newZoneReader("", "", defaultTTLValue, opts)
+	if err := reader.read(strings.NewReader(testZoneHead+"www 60 A 192.0.2.1\nwww 300 A 192.0.2.2\n"), "test.zone"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := convertZoneRecords(reader, "", opts); err == nil || !strings.Contains(err.Error(), "different TTLs") {
+		t.Errorf("got error %v, want the TTL conflict", err)
+	}
+}
+
+func TestIsValidTTLPolicy(t *testing.T) {
+	for _, policy := range []string{TTLPolicyMin, TTLPolicyMax, TTLPolicyError} {
+		if !isValidTTLPolicy(policy) {
+			t.Errorf("isValidTTLPolicy(%q) = false", policy)
+		}
+	}
+	if isValidTTLPolicy("average") {
+		t.Error(`isValidTTLPolicy("average") = true`)
+	}
+}
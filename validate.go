@@ -0,0 +1,521 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// XC dns_zone limits
+const (
+	xcMaxValuesPerRRSet = 100 // values of one RR set
+	xcMaxTXTLength      = 511 // bytes of one TXT value, all its character-strings joined
+	xcMaxLabelLength    = 63
+	xcMaxNameLength     = 253 // fully qualified, without the trailing dot
+	xcMaxTTL            = math.MaxInt32
+)
+
+// SOA parameters XC requires a higher value for, values at or below floor
+// are raised to raiseTo
+var xcSOALimits = []struct {
+	name    string
+	floor   int
+	raiseTo int
+	field   func(*SOAParameters) *int
+}{
+	{"refresh", 3600, 86400, func(soa *SOAParameters) *int { return &soa.Refresh }},
+	{"retry", 7200, 7200, func(soa *SOAParameters) *int { return &soa.Retry }},
+	{"expire", 3600000, 3600000, func(soa *SOAParameters) *int { return &soa.Expire }},
+	{"negative_ttl", 1801, 1801, func(soa *SOAParameters) *int { return &soa.NegativeTTL }},
+	{"ttl", 300, 300, func(soa *SOAParameters) *int { return &soa.TTL }},
+}
+
+// Labels of owner and target names: letters, digits, "-" and "_" (for _tcp
+// and the like) and "/" for RFC 2317 classless reverse zones. "*" may only be
+// the leftmost label of an owner.
+var labelPattern = regexp.MustCompile(`^[A-Za-z0-9_/-]+$`)
+
+// zoneViolation is one XC constraint an RR set breaks. Value is the index of
+// the offending value, or -1 when the whole set is at fault.
+type zoneViolation struct {
+	Group   int // index into rr_set_group, -1 for default_rr_set_group
+	Set     int
+	Value   int
+	Needle  string // text of the offending value, to find the line it came from
+	Message string
+	Source  *zoneRecord // the offending zone file record, when already known
+}
+
+// checkName validates a domain name label by label. Wildcards are only
+// allowed as the leftmost label of owner names.
+func checkName(name string, owner bool) error {
+	if len(name) > xcMaxNameLength {
+		return fmt.Errorf("name %s is %d characters long, at most %d are allowed", name, len(name), xcMaxNameLength)
+	}
+	for i, label := range strings.Split(name, ".") {
+		switch {
+		case label == "":
+			return fmt.Errorf("name %s has an empty label", name)
+		case len(label) > xcMaxLabelLength:
+			return fmt.Errorf("label %s of %s is %d characters long, at most %d are allowed", label, name, len(label), xcMaxLabelLength)
+		case label == "*" && owner && i == 0:
+		case label == "*" && owner:
+			return fmt.Errorf("name %s has a wildcard that is not the leftmost label", name)
+		case !labelPattern.MatchString(label):
+			return fmt.Errorf("label %s of %s has characters XC does not allow", label, name)
+		}
+	}
+	return nil
+}
+
+// rrSetTargets lists the domain names held in the values of an RR set.
+func rrSetTargets(set DNSRecord) []string {
+	var targets []string
+	switch {
+	case set.NSRecord != nil:
+		targets = set.NSRecord.Values
+	case set.PTRRecord != nil:
+		targets = set.PTRRecord.Values
+	case set.CNAMERecord != nil:
+		targets = []string{set.CNAMERecord.Value}
+	case set.MXRecord != nil:
+		for _, value := range set.MXRecord.Values {
+			targets = append(targets, value.Value)
+		}
+	case set.SRVRecord != nil:
+		for _, value := range set.SRVRecord.Values {
+			targets = append(targets, value.Target)
+		}
+	}
+	return targets
+}
+
+// rrSetValueCount counts the values of an RR set.
+func rrSetValueCount(set DNSRecord) int {
+	switch {
+	case set.ARecord != nil:
+		return len(set.ARecord.Values)
+	case set.AAAARecord != nil:
+		return len(set.AAAARecord.Values)
+	case set.TXTRecord != nil:
+		return len(set.TXTRecord.Values)
+	case set.CAARecord != nil:
+		return len(set.CAARecord.Values)
+	case set.NAPTRRecord != nil:
+		return len(set.NAPTRRecord.Values)
+	case set.SSHFPRecord != nil:
+		return len(set.SSHFPRecord.Values)
+	case set.TLSARecord != nil:
+		return len(set.TLSARecord.Values)
+	case set.DSRecord != nil:
+		return len(set.DSRecord.Values)
+	case set.CNAMERecord != nil:
+		return 1
+	}
+	return len(rrSetTargets(set))
+}
+
+// validateZoneConfig checks a converted primary zone against the XC dns_zone
+// constraints: values per RR set, name and label lengths, allowed characters,
+// TTL and SOA ranges, what may be at the apex and CNAME exclusivity.
+func validateZoneConfig(zoneConfig *ZoneConfig) []zoneViolation {
+	primary := zoneConfig.Spec.Primary
+	if primary == nil {
+		return nil
+	}
+	zone := strings.TrimSuffix(zoneConfig.Metadata.Name, ".")
+
+	type rrSetRef struct{ group, set int }
+	var refs []rrSetRef
+	for i := range primary.DefaultRRSetGroup {
+		refs = append(refs, rrSetRef{-1, i})
+	}
+	for g, group := range primary.RRSetGroup {
+		for i := range group.RRSet {
+			refs = append(refs, rrSetRef{g, i})
+		}
+	}
+	setAt := func(ref rrSetRef) DNSRecord {
+		if ref.group < 0 {
+			return primary.DefaultRRSetGroup[ref.set]
+		}
+		return primary.RRSetGroup[ref.group].RRSet[ref.set]
+	}
+
+	// Types present at each name, over all groups, for CNAME exclusivity
+	typesAt := make(map[string][]string)
+	for _, ref := range refs {
+		rrType, name := rrSetTypeAndName(setAt(ref))
+		typesAt[strings.ToLower(name)] = append(typesAt[strings.ToLower(name)], rrType)
+	}
+
+	var violations []zoneViolation
+	for _, ref := range refs {
+		set := setAt(ref)
+		rrType, name := rrSetTypeAndName(set)
+		violation := func(value int, needle, message string) {
+			violations = append(violations, zoneViolation{Group: ref.group, Set: ref.set, Value: value, Needle: needle, Message: message})
+		}
+
+		fullName := zone
+		if name != "" {
+			fullName = name + "." + zone
+		}
+		if err := checkName(fullName, true); err != nil {
+			violation(-1, "", err.Error())
+			continue
+		}
+		if set.TTL < 0 || set.TTL > xcMaxTTL {
+			violation(-1, "", fmt.Sprintf("TTL %d is outside 0 to %d", set.TTL, xcMaxTTL))
+			continue
+		}
+
+		if name == "" && (rrType == "CNAME" || rrType == "DS") {
+			violation(-1, "", fmt.Sprintf("%s records are not allowed at the zone apex", rrType))
+			continue
+		}
+		if rrType == "CNAME" {
+			var others []string
+			for _, otherType := range typesAt[strings.ToLower(name)] {
+				if otherType != "CNAME" && !stringInSlice(otherType, others) {
+					others = append(others, otherType)
+				}
+			}
+			if len(others) > 0 {
+				sort.Strings(others)
+				violation(-1, "", "CNAME record name cannot be shared with other record types: "+strings.Join(others, ", "))
+				continue
+			}
+		}
+
+		// A CNAME has only its one value, a bad target drops the whole set
+		if set.CNAMERecord != nil {
+			if err := checkName(set.CNAMERecord.Value, false); err != nil {
+				violation(-1, "", err.Error())
+			}
+			continue
+		}
+
+		bad := make(map[int]bool)
+		for i, target := range rrSetTargets(set) {
+			if err := checkName(target, false); err != nil {
+				violation(i, target, err.Error())
+				bad[i] = true
+			}
+		}
+		if set.TXTRecord != nil {
+			for i, value := range set.TXTRecord.Values {
+				if len(value) > xcMaxTXTLength {
+					violation(i, value, fmt.Sprintf("TXT value too long (%d), at most %d characters are allowed", len(value), xcMaxTXTLength))
+					bad[i] = true
+				}
+			}
+		}
+		// Values dropped above do not count towards the limit
+		kept := 0
+		for i := 0; i < rrSetValueCount(set); i++ {
+			if bad[i] {
+				continue
+			}
+			if kept++; kept > xcMaxValuesPerRRSet {
+				violation(i, rrSetValueString(set, i), fmt.Sprintf("%s %s has more than %d values", rrType, fullName, xcMaxValuesPerRRSet))
+			}
+		}
+	}
+
+	return violations
+}
+
+// recordTTLViolations checks the TTL of every zone file record, which the TTL
+// policy may hide behind an RR set TTL in range. The offending record's value
+// is dropped, or its whole set when the value cannot be told apart. Sets
+// whose own TTL is out of range are left to validateZoneConfig.
+func recordTTLViolations(zoneConfig *ZoneConfig, records []zoneRecord) []zoneViolation {
+	primary := zoneConfig.Spec.Primary
+	if primary == nil {
+		return nil
+	}
+
+	type rrSetRef struct{ group, set int }
+	refs := make(map[string]rrSetRef)
+	sets := make(map[rrSetRef]DNSRecord)
+	for i, set := range primary.DefaultRRSetGroup {
+		rrType, name := rrSetTypeAndName(set)
+		refs[rrSetKey(rrType, name)] = rrSetRef{-1, i}
+		sets[rrSetRef{-1, i}] = set
+	}
+	for g, group := range primary.RRSetGroup {
+		for i, set := range group.RRSet {
+			rrType, name := rrSetTypeAndName(set)
+			refs[rrSetKey(rrType, name)] = rrSetRef{g, i}
+			sets[rrSetRef{g, i}] = set
+		}
+	}
+
+	var violations []zoneViolation
+	for i := range records {
+		record := &records[i]
+		if record.TTL >= 0 && record.TTL <= xcMaxTTL {
+			continue
+		}
+		ref, exists := refs[rrSetKey(record.Type, record.Name)]
+		if !exists {
+			continue
+		}
+		set := sets[ref]
+		if set.TTL < 0 || set.TTL > xcMaxTTL {
+			continue
+		}
+
+		violation := zoneViolation{Group: ref.group, Set: ref.set, Value: -1, Message: fmt.Sprintf("TTL %d is outside 0 to %d", record.TTL, xcMaxTTL), Source: record}
+		if value := comparableValue(zoneRecordValue(*record)); value != "" && set.CNAMERecord == nil {
+			for j := 0; j < rrSetValueCount(set); j++ {
+				if comparableValue(rrSetValueString(set, j)) == value {
+					violation.Value = j
+					break
+				}
+			}
+		}
+		violations = append(violations, violation)
+	}
+
+	return violations
+}
+
+// rrSetValueString returns value i of an RR set as text, for finding the
+// record it was converted from.
+func rrSetValueString(set DNSRecord, i int) string {
+	switch {
+	case set.ARecord != nil:
+		return set.ARecord.Values[i]
+	case set.AAAARecord != nil:
+		return set.AAAARecord.Values[i]
+	case set.TXTRecord != nil:
+		return set.TXTRecord.Values[i]
+	case set.CAARecord != nil:
+		return set.CAARecord.Values[i].Value
+	}
+	if targets := rrSetTargets(set); i < len(targets) {
+		return targets[i]
+	}
+	return ""
+}
+
+// keepValues returns values without the ones at the indexes in drop.
+func keepValues[T any](values []T, drop map[int]bool) []T {
+	kept := make([]T, 0, len(values))
+	for i, value := range values {
+		if !drop[i] {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
+
+// removeRRSetValues drops the values at the indexes in drop from an RR set.
+func removeRRSetValues(set *DNSRecord, drop map[int]bool) {
+	switch {
+	case set.ARecord != nil:
+		set.ARecord.Values = keepValues(set.ARecord.Values, drop)
+	case set.AAAARecord != nil:
+		set.AAAARecord.Values = keepValues(set.AAAARecord.Values, drop)
+	case set.NSRecord != nil:
+		set.NSRecord.Values = keepValues(set.NSRecord.Values, drop)
+	case set.PTRRecord != nil:
+		set.PTRRecord.Values = keepValues(set.PTRRecord.Values, drop)
+	case set.TXTRecord != nil:
+		set.TXTRecord.Values = keepValues(set.TXTRecord.Values, drop)
+	case set.MXRecord != nil:
+		set.MXRecord.Values = keepValues(set.MXRecord.Values, drop)
+	case set.SRVRecord != nil:
+		set.SRVRecord.Values = keepValues(set.SRVRecord.Values, drop)
+	case set.CAARecord != nil:
+		set.CAARecord.Values = keepValues(set.CAARecord.Values, drop)
+	case set.NAPTRRecord != nil:
+		set.NAPTRRecord.Values = keepValues(set.NAPTRRecord.Values, drop)
+	case set.SSHFPRecord != nil:
+		set.SSHFPRecord.Values = keepValues(set.SSHFPRecord.Values, drop)
+	case set.TLSARecord != nil:
+		set.TLSARecord.Values = keepValues(set.TLSARecord.Values, drop)
+	case set.DSRecord != nil:
+		set.DSRecord.Values = keepValues(set.DSRecord.Values, drop)
+	}
+}
+
+// comparableValue puts a value in one form for comparing zone file rdata with
+// converted values: addresses in canonical form, names lowercase without the
+// trailing dot.
+func comparableValue(value string) string {
+	if ip := net.ParseIP(value); ip != nil {
+		return ip.String()
+	}
+	return strings.ToLower(strings.TrimSuffix(value, "."))
+}
+
+// zoneRecordValue returns the value a zone file record converts to, in the
+// form rrSetValueString returns it, "" for types it does not cover.
+func zoneRecordValue(record zoneRecord) string {
+	values := record.rdataValues()
+	if len(values) == 0 {
+		return ""
+	}
+	switch record.Type {
+	case "TXT":
+		return strings.Join(values, "")
+	case "A", "AAAA":
+		return values[0]
+	case "NS", "PTR", "CNAME":
+		return qualifyName(values[0], record.Origin)
+	case "MX":
+		if len(values) > 1 {
+			return qualifyName(values[1], record.Origin)
+		}
+	case "SRV":
+		if len(values) > 3 {
+			return qualifyName(values[3], record.Origin)
+		}
+	case "CAA":
+		if len(values) > 2 {
+			return values[2]
+		}
+	}
+	return ""
+}
+
+// violationSource finds the zone file record a violation comes from among the
+// records of its RR set: the first one for the whole set, the one holding the
+// offending value otherwise. ok is false when no record is known to match.
+func violationSource(sources []zoneRecord, violation zoneViolation) (zoneRecord, bool) {
+	if violation.Source != nil {
+		return *violation.Source, true
+	}
+	if violation.Value < 0 {
+		if len(sources) > 0 {
+			return sources[0], true
+		}
+		return zoneRecord{}, false
+	}
+	if violation.Needle != "" {
+		needle := comparableValue(violation.Needle)
+		for _, source := range sources {
+			if comparableValue(zoneRecordValue(source)) == needle {
+				return source, true
+			}
+		}
+	}
+	return zoneRecord{}, false
+}
+
+// clampSOAParameters brings the SOA timers into the ranges XC accepts and
+// reports every value it changes.
+func clampSOAParameters(zoneConfig *ZoneConfig, records []zoneRecord, opts conversionOptions) {
+	source := zoneRecord{File: zoneConfig.Metadata.Name, Owner: strings.TrimSuffix(zoneConfig.Metadata.Name, "."), Type: "SOA"}
+	for _, record := range records {
+		if record.Type == "SOA" {
+			source = record
+			break
+		}
+	}
+
+	for _, limit := range xcSOALimits {
+		value := limit.field(&zoneConfig.Spec.Primary.SOAParameters)
+		switch {
+		case *value <= limit.floor && *value != limit.raiseTo:
+			opts.Zone.adjustRecord(source, fmt.Sprintf("SOA %s %d is too low for XC, raised to %d", limit.name, *value, limit.raiseTo))
+			*value = limit.raiseTo
+		case *value > xcMaxTTL:
+			opts.Zone.adjustRecord(source, fmt.Sprintf("SOA %s %d is too high for XC, lowered to %d", limit.name, *value, xcMaxTTL))
+			*value = xcMaxTTL
+		}
+	}
+}
+
+// enforceXCLimits validates the final zone, reports every violation with the
+// line it originates from in the skipped records report, and drops the
+// offending values, or whole RR sets, so XC accepts the zone. SOA timers out
+// of range are adjusted instead.
+func enforceXCLimits(zoneConfig *ZoneConfig, records []zoneRecord, opts conversionOptions) {
+	primary := zoneConfig.Spec.Primary
+	if primary == nil {
+		return
+	}
+
+	sources := make(map[string][]zoneRecord)
+	for _, record := range records {
+		key := rrSetKey(record.Type, record.Name)
+		sources[key] = append(sources[key], record)
+	}
+
+	clampSOAParameters(zoneConfig, records, opts)
+
+	violations := append(validateZoneConfig(zoneConfig), recordTTLViolations(zoneConfig, records)...)
+	if len(violations) == 0 {
+		return
+	}
+
+	rrSetsOf := func(group int) *[]DNSRecord {
+		if group < 0 {
+			return &primary.DefaultRRSetGroup
+		}
+		return &primary.RRSetGroup[group].RRSet
+	}
+
+	for _, violation := range violations {
+		rrType, name := rrSetTypeAndName((*rrSetsOf(violation.Group))[violation.Set])
+		source, ok := violationSource(sources[rrSetKey(rrType, name)], violation)
+		if !ok {
+			// Reported against the zone rather than a line that may be wrong
+			owner := strings.TrimSuffix(zoneConfig.Metadata.Name, ".")
+			if name != "" {
+				owner = name + "." + owner
+			}
+			source = zoneRecord{File: zoneConfig.Metadata.Name, Owner: owner, Type: rrType}
+		}
+		opts.Zone.skipRecord(source, violation.Message)
+	}
+
+	// Collect the values to drop of every set first and rebuild each set once,
+	// a set can break several limits
+	type rrSetRef struct{ group, set int }
+	dropValues := make(map[rrSetRef]map[int]bool)
+	dropSets := make(map[rrSetRef]bool)
+	for _, violation := range violations {
+		ref := rrSetRef{violation.Group, violation.Set}
+		if violation.Value < 0 {
+			dropSets[ref] = true
+			continue
+		}
+		if dropValues[ref] == nil {
+			dropValues[ref] = make(map[int]bool)
+		}
+		dropValues[ref][violation.Value] = true
+	}
+	for ref, drop := range dropValues {
+		set := &(*rrSetsOf(ref.group))[ref.set]
+		removeRRSetValues(set, drop)
+		if rrSetValueCount(*set) == 0 {
+			dropSets[ref] = true
+		}
+	}
+	for ref := range dropSets {
+		(*rrSetsOf(ref.group))[ref.set] = DNSRecord{} // marked, removed below
+	}
+	primary.DefaultRRSetGroup = withoutEmptyRRSets(primary.DefaultRRSetGroup)
+	for g := range primary.RRSetGroup {
+		primary.RRSetGroup[g].RRSet = withoutEmptyRRSets(primary.RRSetGroup[g].RRSet)
+	}
+}
+
+// withoutEmptyRRSets drops the RR sets enforceXCLimits cleared.
+func withoutEmptyRRSets(sets []DNSRecord) []DNSRecord {
+	kept := make([]DNSRecord, 0, len(sets))
+	for _, set := range sets {
+		if rrType, _ := rrSetTypeAndName(set); rrType != "" {
+			kept = append(kept, set)
+		}
+	}
+	return kept
+}
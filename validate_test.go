@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// convertTestZone converts a zone file written from text, collecting what
+// happens to its records in opts.
+func convertTestZone(t *testing.T, zone string, opts conversionOptions) *ZoneConfig {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.zone")
+	if err := os.WriteFile(path, []byte(zone), 0644); err != nil {
+		t.Fatal(err)
+	}
+	zoneConfig, err := ParseZoneFile(path, "", "", opts)
+	if err != nil {
+		t.Fatalf("ParseZoneFile: %v", err)
+	}
+	return zoneConfig
+}
+
+const testZoneHead = `$ORIGIN example.com.
+$TTL 3600
+@ IN SOA ns1.example.com. admin.example.com. 1 86400 7200 3600000 3600
+@ IN NS ns1.example.com.
+ns1 IN A 192.0.2.1
+`
+
+// A set over the value limit that also holds a bad value used to delete by
+// stale indexes and panic.
+func TestEnforceXCLimitsTooLongAndTooMany(t *testing.T) {
+	var zone strings.Builder
+	zone.WriteString(testZoneHead)
+	for i := 0; i < 150; i++ {
+		value := fmt.Sprintf("v%03d", i)
+		if i == 120 {
+			value = strings.Repeat("a", 600)
+		}
+		fmt.Fprintf(&zone, "@ IN TXT %q\n", value)
+	}
+
+	zoneConfig := convertTestZone(t, zone.String(), newConversionOptions())
+
+	var txt *TXTRecord
+	for _, set := range zoneConfig.Spec.Primary.DefaultRRSetGroup {
+		if set.TXTRecord != nil && set.TXTRecord.Name == "" {
+			txt = set.TXTRecord
+		}
+	}
+	if txt == nil {
+		t.Fatal("apex TXT set missing")
+	}
+	if len(txt.Values) != xcMaxValuesPerRRSet {
+		t.Errorf("got %d TXT values, want %d", len(txt.Values), xcMaxValuesPerRRSet)
+	}
+	for _, value := range txt.Values {
+		if len(value) > xcMaxTXTLength {
+			t.Errorf("TXT value of %d characters kept", len(value))
+		}
+	}
+}
+
+// A CNAME reported as not converted must not be written either.
+func TestEnforceXCLimitsDropsCNAMEWithBadTarget(t *testing.T) {
+	target := strings.Repeat("a", 60) + "." + strings.Repeat("b", 60) + "." + strings.Repeat("c", 60) + "." + strings.Repeat("d", 60) + ".example.net."
+	zoneConfig := convertTestZone(t, testZoneHead+"www IN CNAME "+target+"\n", newConversionOptions())
+
+	for _, set := range allRRSets(zoneConfig.Spec.Primary) {
+		if set.CNAMERecord != nil {
+			t.Errorf("CNAME to %s kept", set.CNAMERecord.Value)
+		}
+	}
+}
+
+// A violation of one value is reported on the line of that value, not on
+// another record of the set.
+func TestEnforceXCLimitsReportsOffendingLine(t *testing.T) {
+	opts := newConversionOptions()
+	convertTestZone(t, testZoneHead+
+		"@ IN MX 10 mx.example.com.\n"+
+		"@ IN MX 20 mx."+strings.Repeat("m", 70)+".example.com.\n"+
+		"mx IN A 192.0.2.25\n", opts)
+
+	skipped := opts.Zone.Skipped
+	if len(skipped) != 1 {
+		t.Fatalf("got %d skipped records, want 1: %+v", len(skipped), skipped)
+	}
+	if skipped := skipped[0]; skipped.Line != 7 || skipped.Type != "MX" {
+		t.Errorf("reported at line %d type %s, want line 7 type MX", skipped.Line, skipped.Type)
+	}
+}
+
+// SOA timers XC does not accept are raised, each change reported once.
+func TestEnforceXCLimitsClampsSOA(t *testing.T) {
+	opts := newConversionOptions()
+	zoneConfig := convertTestZone(t, `$ORIGIN example.com.
+$TTL 3600
+@ IN SOA ns1.example.com. admin.example.com. 1 1800 3600 1209600 300
+@ IN NS ns1.example.com.
+`, opts)
+
+	got := zoneConfig.Spec.Primary.SOAParameters
+	want := SOAParameters{Refresh: 86400, Retry: 7200, Expire: 3600000, NegativeTTL: 1801, TTL: 3600}
+	if got != want {
+		t.Errorf("got SOA %+v, want %+v", got, want)
+	}
+	if len(opts.Zone.Adjusted) != 4 {
+		t.Fatalf("got %d adjusted values, want 4: %+v", len(opts.Zone.Adjusted), opts.Zone.Adjusted)
+	}
+	for _, adjusted := range opts.Zone.Adjusted {
+		if adjusted.Line != 3 || !adjusted.Adjusted {
+			t.Errorf("adjustment %q reported at line %d", adjusted.Reason, adjusted.Line)
+		}
+	}
+}
+
+// A record TTL out of range is reported on its line even when the TTL policy
+// gives its set a TTL in range, and only its value is dropped.
+func TestEnforceXCLimitsRecordTTL(t *testing.T) {
+	zone := testZoneHead +
+		"www IN A 192.0.2.10\n" +
+		"www IN A 192.0.2.11\n"
+	zoneConfig := convertTestZone(t, zone, newConversionOptions())
+
+	records := readTestZone(t, zone)
+	for i := range records {
+		if records[i].Type == "A" && records[i].RData[0].Value == "192.0.2.11" {
+			records[i].TTL = xcMaxTTL + 1
+		}
+	}
+	opts := newConversionOptions()
+	enforceXCLimits(zoneConfig, records, opts)
+
+	for _, set := range allRRSets(zoneConfig.Spec.Primary) {
+		if set.ARecord != nil && set.ARecord.Name == "www" {
+			if len(set.ARecord.Values) != 1 || set.ARecord.Values[0] != "192.0.2.10" {
+				t.Errorf("got www values %v, want [192.0.2.10]", set.ARecord.Values)
+			}
+		}
+	}
+	if len(opts.Zone.Skipped) != 1 {
+		t.Fatalf("got %d skipped records, want 1: %+v", len(opts.Zone.Skipped), opts.Zone.Skipped)
+	}
+	if skipped := opts.Zone.Skipped[0]; skipped.Line != 7 || !strings.Contains(skipped.Reason, "TTL") {
+		t.Errorf("reported %q at line %d, want a TTL at line 7", skipped.Reason, skipped.Line)
+	}
+}
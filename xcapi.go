@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Push modes for -push
+const (
+	PushDryRun  = "dry-run" // report what would be done, only reads from the API
+	PushCreate  = "create"  // create the zone, fail if it already exists
+	PushReplace = "replace" // replace the zone, create it if it does not exist
+)
+
+func isValidPushMode(mode string) bool {
+	return mode == PushDryRun || mode == PushCreate || mode == PushReplace
+}
+
+// xcClient talks to the F5 Distributed Cloud (XC) config API, authenticating
+// with an API token or an API certificate.
+type xcClient struct {
+	apiURL string // e.g. https://tenant.console.ves.volterra.io/api
+	token  string
+	client *http.Client
+}
+
+// xcAPIError is an error answer of the XC API.
+type xcAPIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Code       int    `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *xcAPIError) Error() string {
+	message := strings.TrimSpace(e.Message)
+	if message == "" {
+		message = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, message)
+}
+
+// newXCClient returns an API client. The token takes precedence over the
+// certificate when both are given.
+func newXCClient(apiURL, token, p12Path, p12Password string) (*xcClient, error) {
+	apiURL = strings.TrimSuffix(apiURL, "/")
+	if apiURL == "" {
+		return nil, fmt.Errorf("no XC API URL, set -api-url or VOLT_API_URL")
+	}
+	if _, err := url.Parse(apiURL); err != nil {
+		return nil, fmt.Errorf("invalid XC API URL: %v", err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if token == "" {
+		if p12Path == "" {
+			return nil, fmt.Errorf("no XC credentials, set -api-token (VOLTERRA_TOKEN) or -api-p12 (VOLT_API_P12_FILE)")
+		}
+		certificate, err := loadP12Certificate(p12Path, p12Password)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{Certificates: []tls.Certificate{certificate}}
+	}
+
+	return &xcClient{
+		apiURL: apiURL,
+		token:  token,
+		client: &http.Client{Transport: transport, Timeout: 60 * time.Second},
+	}, nil
+}
+
+func (c *xcClient) zonesURL(namespace string) string {
+	return fmt.Sprintf("%s/config/dns/namespaces/%s/dns_zones", c.apiURL, url.PathEscape(namespace))
+}
+
+func (c *xcClient) zoneURL(namespace, name string) string {
+	return c.zonesURL(namespace) + "/" + url.PathEscape(name)
+}
+
+// do sends a request with an optional JSON body and decodes a JSON answer
+// into out, if given.
+func (c *xcClient) do(method, requestURL string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error marshaling to JSON: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequest(method, requestURL, reader)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		request.Header.Set("Authorization", "APIToken "+c.token)
+	}
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		apiError := &xcAPIError{Method: method, URL: requestURL, StatusCode: response.StatusCode}
+		if json.Unmarshal(data, apiError) != nil || apiError.Message == "" {
+			apiError.Message = string(data)
+		}
+		return apiError
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("%s %s: unexpected answer: %v", method, requestURL, err)
+		}
+	}
+	return nil
+}
+
+// GetZone fetches a dns_zone, or returns nil when it does not exist.
+func (c *xcClient) GetZone(namespace, name string) (*ZoneConfig, error) {
+	zoneConfig := &ZoneConfig{}
+	err := c.do(http.MethodGet, c.zoneURL(namespace, name), nil, zoneConfig)
+	if apiError, ok := err.(*xcAPIError); ok && apiError.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return zoneConfig, nil
+}
+
+// CreateZone creates a dns_zone in the namespace of its metadata.
+func (c *xcClient) CreateZone(zoneConfig *ZoneConfig) error {
+	return c.do(http.MethodPost, c.zonesURL(zoneConfig.Metadata.Namespace), zoneConfig, nil)
+}
+
+// ReplaceZone replaces the spec of an existing dns_zone.
+func (c *xcClient) ReplaceZone(zoneConfig *ZoneConfig) error {
+	return c.do(http.MethodPut, c.zoneURL(zoneConfig.Metadata.Namespace, zoneConfig.Metadata.Name), zoneConfig, nil)
+}
+
+// pushZone creates or replaces a converted zone in XC according to mode.
+func pushZone(client *xcClient, namespace string, zoneConfig *ZoneConfig, mode string) error {
+	zoneConfig.Metadata.Namespace = namespace
+	name := zoneConfig.Metadata.Name
+
+	existing, err := client.GetZone(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	switch mode {
+	case PushDryRun:
+		action := "create"
+		if existing != nil {
+			action = "replace"
+		}
+		fmt.Printf("Dry run: would %s dns_zone %s in namespace %s (%s)\n", action, name, namespace, zoneSummary(zoneConfig))
+		return nil
+	case PushCreate:
+		if existing != nil {
+			return fmt.Errorf("dns_zone %s already exists in namespace %s, use -push replace to overwrite it", name, namespace)
+		}
+		err = client.CreateZone(zoneConfig)
+	default:
+		if existing == nil {
+			err = client.CreateZone(zoneConfig)
+		} else {
+			err = client.ReplaceZone(zoneConfig)
+		}
+	}
+	if err != nil {
+		reportAPIValidationErrors(err, zoneConfig)
+		return err
+	}
+
+	fmt.Printf("Pushed dns_zone %s to namespace %s (%s)\n", name, namespace, zoneSummary(zoneConfig))
+	return nil
+}
+
+// pushConvertedZone pushes a zone converted from a named.conf, refusing to
+// push a zone name a second time from another view.
+func pushConvertedZone(zoneConfig *ZoneConfig, view string, opts conversionOptions) error {
+	name := strings.TrimSuffix(zoneConfig.Metadata.Name, ".")
+	if otherView, pushed := opts.Run.Pushed[name]; pushed {
+		return fmt.Errorf("zone %s was already pushed from view %q, XC holds one zone per name", name, otherView)
+	}
+	if err := pushZone(opts.PushClient, opts.Namespace, zoneConfig, opts.PushMode); err != nil {
+		return err
+	}
+	opts.Run.Pushed[name] = view
+	return nil
+}
+
+func zoneSummary(zoneConfig *ZoneConfig) string {
+	if zoneConfig.Spec.Secondary != nil {
+		return "secondary of " + strings.Join(zoneConfig.Spec.Secondary.PrimaryServers, ", ")
+	}
+	if zoneConfig.Spec.Primary == nil {
+		return "no spec"
+	}
+	if groups := len(zoneConfig.Spec.Primary.RRSetGroup); groups > 0 {
+		return fmt.Sprintf("%d RR sets in %d rr_set_group(s) and the default group", countRRSets(zoneConfig.Spec.Primary), groups)
+	}
+	return fmt.Sprintf("%d RR sets", len(zoneConfig.Spec.Primary.DefaultRRSetGroup))
+}
+
+// Field paths of RR sets in XC validation messages, in the default group or
+// in a named one
+var rrSetPathPattern = regexp.MustCompile(`default_rr_set_group\[(\d+)\]|rr_set_group\[(\d+)\]\.rr_set\[(\d+)\]`)
+
+// reportAPIValidationErrors lists the RR sets an API error message refers to,
+// so a rejected zone can be fixed record by record.
+func reportAPIValidationErrors(err error, zoneConfig *ZoneConfig) {
+	apiError, ok := err.(*xcAPIError)
+	if !ok || zoneConfig.Spec.Primary == nil {
+		return
+	}
+
+	// Messages may list several violations, one per line or separated by ";"
+	type rrSetPath struct{ group, index int } // group -1 is the default group
+	lines := strings.FieldsFunc(apiError.Message, func(r rune) bool { return r == '\n' || r == ';' })
+	messages := make(map[rrSetPath][]string)
+	var paths []rrSetPath
+	for _, line := range lines {
+		for _, match := range rrSetPathPattern.FindAllStringSubmatch(line, -1) {
+			path := rrSetPath{group: -1}
+			if match[1] != "" {
+				path.index, _ = strconv.Atoi(match[1])
+			} else {
+				path.group, _ = strconv.Atoi(match[2])
+				path.index, _ = strconv.Atoi(match[3])
+			}
+			if _, seen := messages[path]; !seen {
+				paths = append(paths, path)
+			}
+			messages[path] = append(messages[path], strings.TrimSpace(line))
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if paths[i].group != paths[j].group {
+			return paths[i].group < paths[j].group
+		}
+		return paths[i].index < paths[j].index
+	})
+
+	primary := zoneConfig.Spec.Primary
+	fmt.Printf(ColorRed+"XC rejected %d RR set(s):"+ColorReset+"\n", len(paths))
+	for _, path := range paths {
+		rrSets, label := primary.DefaultRRSetGroup, fmt.Sprintf("[%d]", path.index)
+		if path.group >= 0 {
+			rrSets, label = nil, fmt.Sprintf("rr_set_group[%d][%d]", path.group, path.index)
+			if path.group < len(primary.RRSetGroup) {
+				rrSets = primary.RRSetGroup[path.group].RRSet
+				label = fmt.Sprintf("%s[%d]", primary.RRSetGroup[path.group].Metadata.Name, path.index)
+			}
+		}
+
+		description := "unknown RR set"
+		if path.index < len(rrSets) {
+			rrType, name := rrSetTypeAndName(rrSets[path.index])
+			if name == "" {
+				name = "@"
+			}
+			description = rrType + " " + name
+		}
+		for _, message := range messages[path] {
+			fmt.Printf(ColorYellow+"  %s %s:"+ColorReset+" %s\n", label, description, message)
+		}
+	}
+}
+
+// newXCClientFromFlags builds a client from the flags, falling back to the
+// environment variables the XC Terraform provider uses.
+func newXCClientFromFlags(apiURL, token, p12Path string) (*xcClient, error) {
+	if apiURL == "" {
+		apiURL = os.Getenv("VOLT_API_URL")
+	}
+	if token == "" {
+		token = os.Getenv("VOLTERRA_TOKEN")
+	}
+	if p12Path == "" {
+		p12Path = os.Getenv("VOLT_API_P12_FILE")
+	}
+	return newXCClient(apiURL, token, p12Path, os.Getenv("VES_P12_PASSWORD"))
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testAPIToken = "test-token"
+
+// fakeXC is a stand-in for the dns_zone part of the XC config API.
+type fakeXC struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	zones    map[string]*ZoneConfig // namespace/name -> zone
+	requests []string               // "METHOD path" of every request
+	reject   string                 // message a create or replace is rejected with
+}
+
+func newFakeXC(t *testing.T) *fakeXC {
+	t.Helper()
+	fake := &fakeXC{zones: make(map[string]*ZoneConfig)}
+	fake.server = httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(fake.server.Close)
+	return fake
+}
+
+// client returns an API client of the fake authenticated with token.
+func (fake *fakeXC) client(t *testing.T, token string) *xcClient {
+	t.Helper()
+	client, err := newXCClient(fake.server.URL+"/api", token, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func (fake *fakeXC) serve(w http.ResponseWriter, r *http.Request) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.requests = append(fake.requests, r.Method+" "+r.URL.Path)
+
+	fail := func(status int, message string) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": status, "message": message})
+	}
+	if r.Header.Get("Authorization") != "APIToken "+testAPIToken {
+		fail(http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	// /api/config/dns/namespaces/<ns>/dns_zones[/<name>]
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/config/dns/namespaces/"), "/")
+	if len(parts) < 2 || parts[1] != "dns_zones" {
+		fail(http.StatusNotFound, "no such path")
+		return
+	}
+	namespace := parts[0]
+
+	var body ZoneConfig
+	if r.Method == http.MethodPost || r.Method == http.MethodPut {
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &body); err != nil {
+			fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		if fake.reject != "" {
+			fail(http.StatusBadRequest, fake.reject)
+			return
+		}
+	}
+
+	switch {
+	case r.Method == http.MethodPost && len(parts) == 2:
+		key := namespace + "/" + body.Metadata.Name
+		if fake.zones[key] != nil {
+			fail(http.StatusConflict, "already exists")
+			return
+		}
+		fake.zones[key] = &body
+	case len(parts) == 3:
+		key := namespace + "/" + parts[2]
+		zone := fake.zones[key]
+		if zone == nil {
+			fail(http.StatusNotFound, "dns_zone not found")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(zone)
+			return
+		case http.MethodPut:
+			fake.zones[key] = &body
+		default:
+			fail(http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+	default:
+		fail(http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	w.Write([]byte("{}"))
+}
+
+// methods returns the methods of the requests sent so far.
+func (fake *fakeXC) methods() []string {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	var methods []string
+	for _, request := range fake.requests {
+		methods = append(methods, strings.Fields(request)[0])
+	}
+	return methods
+}
+
+// captureOutput returns what f prints to stdout.
+func captureOutput(t *testing.T, f func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		done <- string(data)
+	}()
+	f()
+	writer.Close()
+	return <-done
+}
+
+// testPrimaryZone returns a primary zone with a record at www.
+func testPrimaryZone(name, address string) *ZoneConfig {
+	zoneConfig := newZoneConfig()
+	zoneConfig.Metadata.Name = name
+	zoneConfig.Spec.Primary = &PrimaryZone{
+		SOAParameters: SOAParameters{Refresh: 86400, Retry: 7200, Expire: 3600000, NegativeTTL: 1801, TTL: 3600},
+		DefaultRRSetGroup: []DNSRecord{
+			{TTL: 3600, ARecord: &ARecord{Name: "www", Values: []string{address}}},
+		},
+	}
+	return zoneConfig
+}
+
+func TestXCClientAuthenticatesWithAPIToken(t *testing.T) {
+	fake := newFakeXC(t)
+
+	if _, err := fake.client(t, "wrong").GetZone("system", "example.com"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("got error %v with a wrong token, want a 401", err)
+	}
+	if _, err := fake.client(t, testAPIToken).GetZone("system", "example.com"); err != nil {
+		t.Errorf("got error %v with the right token", err)
+	}
+}
+
+func TestGetZoneNotFound(t *testing.T) {
+	fake := newFakeXC(t)
+
+	zoneConfig, err := fake.client(t, testAPIToken).GetZone("system", "missing.example.com")
+	if zoneConfig != nil || err != nil {
+		t.Errorf("got %v, %v for a missing zone, want nil, nil", zoneConfig, err)
+	}
+}
+
+func TestPushZoneCreateAndReplace(t *testing.T) {
+	fake := newFakeXC(t)
+	client := fake.client(t, testAPIToken)
+
+	if err := pushZone(client, "shared", testPrimaryZone("example.com", "192.0.2.1"), PushDryRun); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if err := pushZone(client, "shared", testPrimaryZone("example.com", "192.0.2.1"), PushCreate); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := pushZone(client, "shared", testPrimaryZone("example.com", "192.0.2.2"), PushCreate); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("got error %v creating an existing zone, want already exists", err)
+	}
+	if err := pushZone(client, "shared", testPrimaryZone("example.com", "192.0.2.2"), PushReplace); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+
+	// Every push reads the zone first, a dry run and a refused create write nothing
+	want := "GET GET POST GET GET PUT"
+	if got := strings.Join(fake.methods(), " "); got != want {
+		t.Errorf("got requests %s, want %s", got, want)
+	}
+	zone := fake.zones["shared/example.com"]
+	if zone == nil || zone.Spec.Primary.DefaultRRSetGroup[0].ARecord.Values[0] != "192.0.2.2" {
+		t.Errorf("zone was not replaced: %+v", zone)
+	}
+}
+
+func TestPushZoneReportsValidationErrors(t *testing.T) {
+	fake := newFakeXC(t)
+	fake.reject = "spec.primary.default_rr_set_group[0].a_record.values[0]: invalid IPv4 address"
+
+	var err error
+	output := captureOutput(t, func() {
+		err = pushZone(fake.client(t, testAPIToken), "system", testPrimaryZone("example.com", "192.0.2.300"), PushReplace)
+	})
+
+	apiError, ok := err.(*xcAPIError)
+	if !ok || apiError.StatusCode != http.StatusBadRequest {
+		t.Fatalf("got error %v, want a 400 xcAPIError", err)
+	}
+	if !strings.Contains(output, "XC rejected 1 RR set(s)") || !strings.Contains(output, "A www") || !strings.Contains(output, "invalid IPv4 address") {
+		t.Errorf("validation error not reported against the RR set:\n%s", output)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Plain YAML scalars that would not be read back as strings
+var yamlReservedWords = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true,
+	"y": true, "n": true, "null": true, "~": true, ".inf": true, ".nan": true,
+}
+
+// yamlString writes a string plain when YAML reads it back as the same
+// string, and double quoted otherwise. Anything starting like a number is
+// quoted, so IP addresses and versions stay strings for YAML 1.1 readers.
+func yamlString(value string) string {
+	plain := value != "" && strings.TrimSpace(value) == value &&
+		!strings.ContainsAny(value[:1], "-?:,[]{}#&*!|>'\"%@`.+0123456789") &&
+		!strings.HasSuffix(value, ":") &&
+		!strings.Contains(value, ": ") && !strings.Contains(value, " #") &&
+		!yamlReservedWords[strings.ToLower(value)]
+	for _, r := range value {
+		if r < 0x20 || r == 0x7f {
+			plain = false
+		}
+	}
+	if plain {
+		return value
+	}
+
+	// A JSON string is a valid YAML double quoted scalar
+	var quoted bytes.Buffer
+	encoder := json.NewEncoder(&quoted)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	return strings.TrimSuffix(quoted.String(), "\n")
+}
+
+// yamlScalar renders a JSON scalar as a YAML scalar.
+func yamlScalar(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return yamlString(value)
+	case json.Number:
+		return value.String()
+	case bool:
+		return fmt.Sprint(value)
+	}
+	return "null"
+}
+
+// writeYAMLFields writes the fields of an object as a block mapping. The
+// first field is prefixed with first instead of indent, which is how a
+// mapping starts on the "- " line of a sequence item.
+func writeYAMLFields(yaml *bytes.Buffer, fields []orderedField, indent, first string) {
+	for i, field := range fields {
+		prefix := indent
+		if i == 0 {
+			prefix = first
+		}
+		key := yamlString(field.Name)
+
+		switch {
+		case field.Value.Kind == '{' && len(field.Value.Fields) == 0:
+			fmt.Fprintf(yaml, "%s%s: {}\n", prefix, key)
+		case field.Value.Kind == '{':
+			fmt.Fprintf(yaml, "%s%s:\n", prefix, key)
+			writeYAMLFields(yaml, field.Value.Fields, indent+"  ", indent+"  ")
+		case field.Value.Kind == '[' && len(field.Value.Items) == 0:
+			fmt.Fprintf(yaml, "%s%s: []\n", prefix, key)
+		case field.Value.Kind == '[':
+			fmt.Fprintf(yaml, "%s%s:\n", prefix, key)
+			for _, item := range field.Value.Items {
+				writeYAMLItem(yaml, item, indent+"  ")
+			}
+		default:
+			fmt.Fprintf(yaml, "%s%s: %s\n", prefix, key, yamlScalar(field.Value.Scalar))
+		}
+	}
+}
+
+// writeYAMLItem writes one item of a block sequence.
+func writeYAMLItem(yaml *bytes.Buffer, item orderedValue, indent string) {
+	switch {
+	case item.Kind == '{' && len(item.Fields) == 0:
+		fmt.Fprintf(yaml, "%s- {}\n", indent)
+	case item.Kind == '{':
+		writeYAMLFields(yaml, item.Fields, indent+"  ", indent+"- ")
+	case item.Kind == '[' && len(item.Items) == 0:
+		fmt.Fprintf(yaml, "%s- []\n", indent)
+	case item.Kind == '[':
+		fmt.Fprintf(yaml, "%s-\n", indent)
+		for _, nested := range item.Items {
+			writeYAMLItem(yaml, nested, indent+"  ")
+		}
+	default:
+		fmt.Fprintf(yaml, "%s- %s\n", indent, yamlScalar(item.Scalar))
+	}
+}
+
+// renderYAML renders a zone as the YAML object vesctl configuration create
+// dns_zone reads, with the same field names and order as the JSON output.
+// vesctl takes the namespace from the object, so defaultNamespace (-namespace)
+// fills it in.
+func renderYAML(zoneConfig *ZoneConfig, defaultNamespace string) ([]byte, error) {
+	object := *zoneConfig
+	if object.Metadata.Namespace == "" {
+		object.Metadata.Namespace = defaultNamespace
+	}
+	zoneJSON, err := json.Marshal(object)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling to JSON: %v", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(zoneJSON))
+	decoder.UseNumber()
+	zone, err := decodeOrdered(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	var yaml bytes.Buffer
+	writeYAMLFields(&yaml, zone.Fields, "", "")
+	return yaml.Bytes(), nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import "testing"
+
+func TestYAMLString(t *testing.T) {
+	for _, test := range []struct{ value, want string }{
+		{"mail.example.com", "mail.example.com"},
+		{"v=spf1 -all", "v=spf1 -all"},
+		{"", `""`},
+		{"192.0.2.1", `"192.0.2.1"`},
+		{"2001:db8::1", `"2001:db8::1"`},
+		{"yes", `"yes"`},
+		{"Off", `"Off"`},
+		{"null", `"null"`},
+		{"-all", `"-all"`},
+		{"@", `"@"`},
+		{".", `"."`},
+		{"key: value", `"key: value"`},
+		{"ends:", `"ends:"`},
+		{"a #comment", `"a #comment"`},
+		{" padded", `" padded"`},
+		{`say "hi" <b>`, `say "hi" <b>`},
+		{"tab\there", `"tab\there"`},
+	} {
+		if got := yamlString(test.value); got != test.want {
+			t.Errorf("yamlString(%q) = %s, want %s", test.value, got, test.want)
+		}
+	}
+}
+
+func TestRenderYAML(t *testing.T) {
+	zoneConfig := testPrimaryZone("example.com", "192.0.2.1")
+	zoneConfig.Metadata.Labels["team"] = "dns"
+	zoneConfig.Spec.Primary.DefaultRRSetGroup = append(zoneConfig.Spec.Primary.DefaultRRSetGroup,
+		DNSRecord{TTL: 300, MXRecord: &MXRecord{Values: []MXValue{{Priority: 10, Value: "mail.example.com"}}}},
+		DNSRecord{TTL: 300, TXTRecord: &TXTRecord{Name: "txt", Values: []string{"v=spf1 -all", "yes"}}})
+
+	got, err := renderYAML(zoneConfig, "system")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `metadata:
+  name: example.com
+  namespace: system
+  labels:
+    team: dns
+  annotations: {}
+  description: Zone Converted from BIND Zone File by MC Tool
+  disable: false
+spec:
+  primary:
+    soa_parameters:
+      refresh: 86400
+      retry: 7200
+      expire: 3600000
+      negative_ttl: 1801
+      ttl: 3600
+    default_rr_set_group:
+      - ttl: 3600
+        a_record:
+          name: www
+          values:
+            - "192.0.2.1"
+      - ttl: 300
+        mx_record:
+          values:
+            - priority: 10
+              value: mail.example.com
+      - ttl: 300
+        txt_record:
+          name: txt
+          values:
+            - v=spf1 -all
+            - "yes"
+    dnssec_mode:
+      disable: {}
+`
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if zoneConfig.Metadata.Namespace != "" {
+		t.Errorf("rendering set the namespace of the zone to %q", zoneConfig.Metadata.Namespace)
+	}
+}
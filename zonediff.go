@@ -0,0 +1,286 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// zoneDifference is one record that differs between a BIND zone and an XC
+// zone: "+" only in XC, "-" only in BIND, "~" in both with another TTL.
+type zoneDifference struct {
+	Change string
+	Record bindRecord
+	OldTTL int
+}
+
+// zoneDefaultTTL is the TTL of RR sets that do not carry one.
+func zoneDefaultTTL(zoneConfig *ZoneConfig) int {
+	if zoneConfig.Spec.Primary != nil && zoneConfig.Spec.Primary.SOAParameters.TTL > 0 {
+		return zoneConfig.Spec.Primary.SOAParameters.TTL
+	}
+	return defaultTTLValue
+}
+
+// diffKey identifies a record regardless of its TTL. Domain names compare
+// case-insensitively, TXT and CAA data does not, and addresses compare in
+// their canonical form.
+func diffKey(record bindRecord) string {
+	rdata := record.RData
+	if ip := net.ParseIP(rdata); ip != nil && (record.Type == "A" || record.Type == "AAAA") {
+		rdata = ip.String()
+	}
+	if record.Type != "TXT" && record.Type != "CAA" {
+		rdata = strings.ToLower(rdata)
+	}
+	return strings.ToLower(record.Owner) + " " + record.Type + " " + rdata
+}
+
+// diffZones compares the records of two zones.
+func diffZones(bindZone, xcZone *ZoneConfig) ([]zoneDifference, error) {
+	bindRecords, err := zoneConfigRecords(bindZone, zoneDefaultTTL(bindZone))
+	if err != nil {
+		return nil, err
+	}
+	xcRecords, err := zoneConfigRecords(xcZone, zoneDefaultTTL(xcZone))
+	if err != nil {
+		return nil, err
+	}
+
+	differences := diffRecords(bindRecords, xcRecords)
+	if bindZone.Spec.Primary != nil && xcZone.Spec.Primary != nil {
+		differences = append(differences, diffSOA(bindZone.Spec.Primary.SOAParameters, xcZone.Spec.Primary.SOAParameters)...)
+	}
+	return differences, nil
+}
+
+// diffZoneFile compares the records of a zone file, as they are written, with
+// an XC zone. Nothing of the conversion is applied to the zone file side, so
+// TTLs merged into one per RR set, clamped SOA timers and records that were
+// not converted all show up as differences.
+func diffZoneFile(records []zoneRecord, xcZone *ZoneConfig) ([]zoneDifference, error) {
+	xcRecords, err := zoneConfigRecords(xcZone, zoneDefaultTTL(xcZone))
+	if err != nil {
+		return nil, err
+	}
+
+	var bindRecords []bindRecord
+	var soa *SOAParameters
+	for _, record := range records {
+		if record.Type == "SOA" {
+			// Only the first SOA is the zone's, processSOA does not clamp
+			parsed := SOAParameters{}
+			if soa == nil && processSOA(record.rdataValues(), record.TTL, &parsed) == nil {
+				soa = &parsed
+			}
+			continue
+		}
+		bindRecords = append(bindRecords, zoneFileRecord(record))
+	}
+
+	differences := diffRecords(bindRecords, xcRecords)
+	if soa != nil && xcZone.Spec.Primary != nil {
+		differences = append(differences, diffSOA(*soa, xcZone.Spec.Primary.SOAParameters)...)
+	}
+	return differences, nil
+}
+
+// zoneFileRecord writes a record of a zone file in the master file form of
+// zoneConfigRecords, with its own TTL. Relative names are qualified with the
+// $ORIGIN the record was written under.
+func zoneFileRecord(record zoneRecord) bindRecord {
+	owner := record.Name
+	if owner == "" {
+		owner = "@"
+	}
+	values := record.rdataValues()
+	target := func(name string) string {
+		return absoluteTarget(qualifyName(name, record.Origin))
+	}
+
+	var rdata string
+	switch {
+	case (record.Type == "NS" || record.Type == "CNAME" || record.Type == "PTR") && len(values) == 1:
+		rdata = target(values[0])
+	case record.Type == "MX" && len(values) == 2:
+		rdata = values[0] + " " + target(values[1])
+	case record.Type == "SRV" && len(values) == 4:
+		rdata = strings.Join(values[:3], " ") + " " + target(values[3])
+	case record.Type == "TXT":
+		rdata = quoteTXT(strings.Join(values, ""))
+	case record.Type == "CAA" && len(values) == 3:
+		rdata = values[0] + " " + strings.ToLower(values[1]) + " " + quoteCharacterString([]byte(values[2]))
+	case record.Type == "NAPTR" && len(values) == 6:
+		replacement := "."
+		if values[5] != "." {
+			replacement = target(values[5])
+		}
+		rdata = fmt.Sprintf("%s %s %s %s %s %s", values[0], values[1],
+			quoteCharacterString([]byte(values[2])), quoteCharacterString([]byte(values[3])), quoteCharacterString([]byte(values[4])), replacement)
+	case record.Type == "SSHFP" && len(values) >= 3:
+		rdata = strings.Join(values[:2], " ") + " " + strings.ToUpper(strings.Join(values[2:], ""))
+	case (record.Type == "TLSA" || record.Type == "DS") && len(values) >= 4:
+		rdata = strings.Join(values[:3], " ") + " " + strings.ToUpper(strings.Join(values[3:], ""))
+	default:
+		fields := make([]string, len(record.RData))
+		for i, token := range record.RData {
+			fields[i] = token.Value
+			if token.Quoted {
+				fields[i] = quoteCharacterString([]byte(token.Value))
+			}
+		}
+		rdata = strings.Join(fields, " ")
+	}
+
+	return bindRecord{Owner: owner, TTL: record.TTL, Type: record.Type, RData: rdata}
+}
+
+// diffRecords compares two lists of records, ordered by owner and type.
+func diffRecords(bindRecords, xcRecords []bindRecord) []zoneDifference {
+	inBIND := make(map[string]bindRecord)
+	for _, record := range bindRecords {
+		inBIND[diffKey(record)] = record
+	}
+	inXC := make(map[string]bool)
+
+	var differences []zoneDifference
+	for _, record := range xcRecords {
+		key := diffKey(record)
+		inXC[key] = true
+
+		bindRecord, exists := inBIND[key]
+		switch {
+		case !exists:
+			differences = append(differences, zoneDifference{Change: "+", Record: record})
+		case bindRecord.TTL != record.TTL:
+			differences = append(differences, zoneDifference{Change: "~", Record: record, OldTTL: bindRecord.TTL})
+		}
+	}
+	for _, record := range bindRecords {
+		key := diffKey(record)
+		if !inXC[key] {
+			// Reported once, a zone file may repeat a record
+			inXC[key] = true
+			differences = append(differences, zoneDifference{Change: "-", Record: record})
+		}
+	}
+
+	sort.SliceStable(differences, func(i, j int) bool {
+		return lessBindRecord(differences[i].Record, differences[j].Record)
+	})
+	return differences
+}
+
+// diffSOA compares SOA timers field by field.
+func diffSOA(bindSOA, xcSOA SOAParameters) []zoneDifference {
+	var differences []zoneDifference
+	for _, field := range []struct {
+		name     string
+		bind, xc int
+	}{
+		{"refresh", bindSOA.Refresh, xcSOA.Refresh},
+		{"retry", bindSOA.Retry, xcSOA.Retry},
+		{"expire", bindSOA.Expire, xcSOA.Expire},
+		{"negative_ttl", bindSOA.NegativeTTL, xcSOA.NegativeTTL},
+		{"ttl", bindSOA.TTL, xcSOA.TTL},
+	} {
+		if field.bind != field.xc {
+			differences = append(differences, zoneDifference{
+				Change: "~",
+				Record: bindRecord{Owner: "@", Type: "SOA", RData: fmt.Sprintf("%s %d -> %d", field.name, field.bind, field.xc)},
+			})
+		}
+	}
+	return differences
+}
+
+// printZoneDifferences prints the differences, removed records in red and
+// added ones in green.
+func printZoneDifferences(differences []zoneDifference) {
+	for _, difference := range differences {
+		record := difference.Record
+		switch difference.Change {
+		case "+":
+			fmt.Printf(ColorGreen+"+ %s %d %s %s"+ColorReset+"\n", record.Owner, record.TTL, record.Type, record.RData)
+		case "-":
+			fmt.Printf(ColorRed+"- %s %d %s %s"+ColorReset+"\n", record.Owner, record.TTL, record.Type, record.RData)
+		default:
+			if record.Type == "SOA" {
+				fmt.Printf(ColorYellow+"~ @ SOA %s"+ColorReset+"\n", record.RData)
+				continue
+			}
+			fmt.Printf(ColorYellow+"~ %s %s %s ttl %d -> %d"+ColorReset+"\n", record.Owner, record.Type, record.RData, difference.OldTTL, record.TTL)
+		}
+	}
+}
+
+// runDiff implements the diff subcommand:
+//
+//	bindtoxcdns diff [-origin zone] [-root path] <zone file> <xc json>
+//
+// It exits with status 1 when the zones differ, like diff(1).
+func runDiff(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	customOrigin := flags.String("origin", "", "Optional origin to override $ORIGIN in the zone file")
+	bindFileRootPath := flags.String("root", ".", "BIND file root path for resolving file references")
+	flags.Usage = func() {
+		fmt.Println("Usage: program diff [-origin <optional_origin>] [-root <bind_file_root_path>] <zone_file> <xc_json_file>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+	zoneFilePath, xcPath := flags.Arg(0), flags.Arg(1)
+
+	xcZone, err := readZoneConfig(xcPath)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 2
+	}
+
+	// Without -origin the zone file is read as the zone named in the XC file
+	origin := *customOrigin
+	if origin == "" {
+		origin = xcZone.Metadata.Name
+	}
+
+	// The zone file is compared as written, not as converted
+	opts := newConversionOptions()
+	reader := newZoneReader(resolveOrigin(origin), *bindFileRootPath, defaultTTLValue, opts)
+	if err := reader.readFile(zoneFilePath); err != nil {
+		fmt.Printf("Error parsing zone file: %v\n", err)
+		return 2
+	}
+	if !strings.EqualFold(reader.apex, strings.TrimSuffix(xcZone.Metadata.Name, ".")) {
+		fmt.Printf(ColorRed+"Warning:"+ColorYellow+" comparing zone %s with XC zone %s"+ColorReset+"\n", reader.apex, xcZone.Metadata.Name)
+	}
+
+	differences, err := diffZoneFile(reader.records, xcZone)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 2
+	}
+
+	printZoneDifferences(differences)
+
+	counts := make(map[string]int)
+	for _, difference := range differences {
+		counts[difference.Change]++
+	}
+	fmt.Printf("%d added, %d removed, %d changed\n", counts["+"], counts["-"], counts["~"])
+
+	// Entries that are not records at all were reported while reading
+	if len(opts.Zone.Skipped) > 0 {
+		fmt.Printf(ColorYellow+"%d entr(ies) of the zone file could not be read and were not compared"+ColorReset+"\n", len(opts.Zone.Skipped))
+	}
+
+	if len(differences) > 0 {
+		return 1
+	}
+	return 0
+}
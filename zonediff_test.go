@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The zone file side of a diff is the file as written, so what the conversion
+// changes shows up against its own output.
+func TestDiffZoneFileShowsConversionChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.zone")
+	zone := `$ORIGIN example.com.
+$TTL 3600
+@ IN SOA ns1.example.com. admin.example.com. 1 1800 7200 3600000 3600
+@ IN NS ns1
+ns1 IN A 192.0.2.1
+www 60 IN A 192.0.2.10
+www IN A 192.0.2.11
+mail IN MX 10 mx.example.net.
+h IN HINFO "PC" "Linux"
+`
+	if err := os.WriteFile(path, []byte(zone), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := newConversionOptions()
+	opts.TTLPolicy = TTLPolicyMax
+	xcZone, err := ParseZoneFile(path, "", "", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader := newZoneReader("", "", defaultTTLValue, newConversionOptions())
+	if err := reader.readFile(path); err != nil {
+		t.Fatal(err)
+	}
+	differences, err := diffZoneFile(reader.records, xcZone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []zoneDifference{
+		{Change: "-", Record: bindRecord{Owner: "h", TTL: 3600, Type: "HINFO", RData: `"PC" "Linux"`}},
+		{Change: "~", Record: bindRecord{Owner: "www", TTL: 3600, Type: "A", RData: "192.0.2.10"}, OldTTL: 60},
+		{Change: "~", Record: bindRecord{Owner: "@", Type: "SOA", RData: "refresh 1800 -> 86400"}},
+	}
+	if len(differences) != len(want) {
+		t.Fatalf("got %d differences, want %d: %+v", len(differences), len(want), differences)
+	}
+	for i := range want {
+		if differences[i] != want[i] {
+			t.Errorf("difference %d: got %+v, want %+v", i, differences[i], want[i])
+		}
+	}
+}
+
+// Relative names, split TXT strings and addresses written differently compare
+// equal to their converted form.
+func TestDiffZoneFileCanonicalForms(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.zone")
+	zone := `$ORIGIN example.com.
+$TTL 3600
+@ IN SOA ns1.example.com. admin.example.com. 1 86400 7200 3600000 3600
+@ IN NS ns1
+ns1 IN A 192.0.2.1
+v6 IN AAAA 2001:DB8:0:0::1
+alias IN CNAME www
+www IN A 192.0.2.10
+@ IN TXT "v=spf1 " "-all"
+_sip._tcp IN SRV 10 20 5060 sip
+sip IN A 192.0.2.20
+`
+	if err := os.WriteFile(path, []byte(zone), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	xcZone, err := ParseZoneFile(path, "", "", newConversionOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := newZoneReader("", "", defaultTTLValue, newConversionOptions())
+	if err := reader.readFile(path); err != nil {
+		t.Fatal(err)
+	}
+	differences, err := diffZoneFile(reader.records, xcZone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(differences) != 0 {
+		t.Errorf("got differences %+v, want none", differences)
+	}
+}
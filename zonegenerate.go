@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// generateRange is the "start-stop[/step]" argument of a $GENERATE directive.
+type generateRange struct {
+	Start, Stop, Step int
+}
+
+// BIND refuses ranges of more than 65535 iterations, anything larger is almost
+// certainly a typo and would exhaust memory
+const maxGenerateIterations = 65535
+
+var errGenerateTooLarge = errors.New("$GENERATE range too large")
+
+func parseGenerateRange(s string) (generateRange, error) {
+	r := generateRange{Step: 1}
+
+	bounds := s
+	if slash := strings.Index(s, "/"); slash != -1 {
+		step, err := strconv.Atoi(s[slash+1:])
+		if err != nil || step <= 0 {
+			return r, fmt.Errorf("invalid $GENERATE step in range %q", s)
+		}
+		r.Step = step
+		bounds = s[:slash]
+	}
+
+	dash := strings.Index(bounds, "-")
+	if dash == -1 {
+		return r, fmt.Errorf("invalid $GENERATE range %q, expected start-stop[/step]", s)
+	}
+	start, errStart := strconv.Atoi(bounds[:dash])
+	stop, errStop := strconv.Atoi(bounds[dash+1:])
+	if errStart != nil || errStop != nil || start < 0 || stop < start {
+		return r, fmt.Errorf("invalid $GENERATE range %q", s)
+	}
+	r.Start, r.Stop = start, stop
+
+	if iterations := (stop-start)/r.Step + 1; iterations > maxGenerateIterations {
+		return r, fmt.Errorf("%w: %q has %d iterations, at most %d are allowed", errGenerateTooLarge, s, iterations, maxGenerateIterations)
+	}
+
+	return r, nil
+}
+
+// expandGenerate builds the zone file lines described by
+//
+//	$GENERATE range lhs [ttl] [class] type rhs
+//
+// one per value in range. The owner and every token of the rdata are
+// templates, so "MX 10 mail$" and multi-string TXT data work. The lines still
+// need to be tokenized and parsed like any other entry, which keeps owner, TTL
+// and class handling in one place.
+func expandGenerate(args []zoneToken) ([]string, error) {
+	if len(args) < 4 {
+		return nil, fmt.Errorf("$GENERATE needs a range, owner, type and rdata")
+	}
+
+	r, err := parseGenerateRange(args[0].Value)
+	if err != nil {
+		return nil, err
+	}
+
+	lhs := args[1].Text
+	_, _, _, typeIndex := splitRecordHeader(args[2:])
+	if 3+typeIndex >= len(args) {
+		return nil, fmt.Errorf("$GENERATE needs a range, owner, type and rdata")
+	}
+	// "[ttl] [class] type" is copied as is
+	var header []string
+	for _, token := range args[2 : 3+typeIndex] {
+		header = append(header, token.Text)
+	}
+	rhs := args[3+typeIndex:]
+
+	var lines []string
+	for i := r.Start; i <= r.Stop; i += r.Step {
+		owner, err := substituteGenerate(lhs, i)
+		if err != nil {
+			return nil, err
+		}
+
+		fields := append([]string{owner}, header...)
+		for _, token := range rhs {
+			rdata, err := substituteGenerate(token.Text, i)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, rdata)
+		}
+		lines = append(lines, strings.Join(fields, " "))
+	}
+
+	return lines, nil
+}
+
+// substituteGenerate replaces each unescaped '$' in template with the iterator
+// value, honouring the ${offset[,width[,base]]} modifier form. "$$" is a
+// literal '$'. It is written as "\$", and "\$" is left as is, so the lexer
+// turns both into a literal '$'.
+func substituteGenerate(template string, value int) (string, error) {
+	var b strings.Builder
+
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		switch {
+		case c == '\\' && i+1 < len(template):
+			b.WriteByte(c)
+			b.WriteByte(template[i+1])
+			i++
+		case c == '$' && i+1 < len(template) && template[i+1] == '$':
+			b.WriteString(`\$`)
+			i++
+		case c == '$' && i+1 < len(template) && template[i+1] == '{':
+			end := strings.Index(template[i:], "}")
+			if end == -1 {
+				return "", fmt.Errorf("unterminated modifier in $GENERATE template %q", template)
+			}
+			formatted, err := formatGenerateModifier(template[i+2:i+end], value)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(formatted)
+			i += end
+		case c == '$':
+			b.WriteString(strconv.Itoa(value))
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// formatGenerateModifier formats value according to "offset[,width[,base]]",
+// where base is one of d, o, x, X, n or N (nibble format for ip6.arpa names).
+func formatGenerateModifier(modifier string, value int) (string, error) {
+	parts := strings.Split(modifier, ",")
+	if len(parts) > 3 {
+		return "", fmt.Errorf("invalid $GENERATE modifier ${%s}", modifier)
+	}
+
+	offset, width, base := 0, 0, "d"
+	var err error
+	if parts[0] != "" {
+		if offset, err = strconv.Atoi(parts[0]); err != nil {
+			return "", fmt.Errorf("invalid $GENERATE offset in ${%s}", modifier)
+		}
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		if width, err = strconv.Atoi(parts[1]); err != nil || width < 0 {
+			return "", fmt.Errorf("invalid $GENERATE width in ${%s}", modifier)
+		}
+	}
+	if len(parts) > 2 {
+		base = parts[2]
+	}
+
+	value += offset
+	if value < 0 {
+		return "", fmt.Errorf("$GENERATE modifier ${%s} gives a negative value", modifier)
+	}
+
+	switch base {
+	case "d":
+		return fmt.Sprintf("%0*d", width, value), nil
+	case "o":
+		return fmt.Sprintf("%0*o", width, value), nil
+	case "x":
+		return fmt.Sprintf("%0*x", width, value), nil
+	case "X":
+		return fmt.Sprintf("%0*X", width, value), nil
+	case "n", "N":
+		return formatNibbles(value, width, base == "N"), nil
+	}
+
+	return "", fmt.Errorf("invalid $GENERATE base %q in ${%s}", base, modifier)
+}
+
+// formatNibbles writes value as dot separated hex nibbles, least significant
+// first, padded with zero nibbles to at least width characters.
+func formatNibbles(value, width int, upper bool) string {
+	digits := strconv.FormatInt(int64(value), 16)
+	if upper {
+		digits = strings.ToUpper(digits)
+	}
+
+	// Each nibble takes two characters ("f.") except the last one
+	for len(digits)*2-1 < width {
+		digits = "0" + digits
+	}
+
+	nibbles := make([]string, len(digits))
+	for i := range digits {
+		nibbles[len(digits)-1-i] = digits[i : i+1]
+	}
+
+	return strings.Join(nibbles, ".")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseGenerateRangeLimit(t *testing.T) {
+	for _, test := range []struct {
+		value string
+		ok    bool
+	}{
+		{"0-65534", true},
+		{"1-131070/2", true},
+		{"0-65535", false},
+		{"0-4294967295", false},
+	} {
+		_, err := parseGenerateRange(test.value)
+		if (err == nil) != test.ok {
+			t.Errorf("parseGenerateRange(%q) error %v, want ok %v", test.value, err, test.ok)
+		}
+	}
+}
+
+// An oversized range stops the read with the line it is on instead of
+// expanding it.
+func TestGenerateTooLargeStopsRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "generate.zone")
+	zone := "$ORIGIN example.com.\n$TTL 3600\n@ IN NS ns1.example.com.\n$GENERATE 0-4294967295 host-$ A 192.0.2.$\n"
+	if err := os.WriteFile(path, []byte(zone), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ParseZoneFile(path, "", "", newConversionOptions())
+	if err == nil || !strings.Contains(err.Error(), path+": line 4:") {
+		t.Errorf("got error %v, want one for %s line 4", err, path)
+	}
+}
+
+// readTestZone reads zone file text into records without converting them.
+func readTestZone(t *testing.T, zone string) []zoneRecord {
+	t.Helper()
+	reader := newZoneReader("", "", defaultTTLValue, newConversionOptions())
+	if err := reader.read(strings.NewReader(zone), "test.zone"); err != nil {
+		t.Fatal(err)
+	}
+	return reader.records
+}
+
+func TestGenerateTemplates(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		directive string
+		want      []string // owner, type and rdata values of each record
+	}{
+		{"last rdata token", "$GENERATE 1-2 host$ A 192.0.2.$",
+			[]string{"host1.example.com A [192.0.2.1]", "host2.example.com A [192.0.2.2]"}},
+		{"mx target", "$GENERATE 1-2 @ MX 10 mail$",
+			[]string{"example.com MX [10 mail1]", "example.com MX [10 mail2]"}},
+		{"every rdata token", "$GENERATE 1-1 _sip$._tcp SRV $ 0 506$ sip$",
+			[]string{"_sip1._tcp.example.com SRV [1 0 5061 sip1]"}},
+		{"txt strings", `$GENERATE 3-3 t$ TXT "first $" "second ${1}"`,
+			[]string{"t3.example.com TXT [first 3 second 4]"}},
+		{"ttl and class", "$GENERATE 7-7 h$ 60 IN A 192.0.2.$",
+			[]string{"h7.example.com A [192.0.2.7]"}},
+		{"literal dollar", `$GENERATE 1-1 p$ TXT "cost $$$ and \$"`,
+			[]string{"p1.example.com TXT [cost $1 and $]"}},
+		{"modifiers", "$GENERATE 10-10 ${-9,3,d} CNAME host${0,2,x}",
+			[]string{"001.example.com CNAME [host0a]"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			records := readTestZone(t, "$ORIGIN example.com.\n"+test.directive+"\n")
+
+			var got []string
+			for _, record := range records {
+				got = append(got, fmt.Sprintf("%s %s %v", record.Owner, record.Type, record.rdataValues()))
+			}
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// zoneToken is a single lexical item read from a master file. Quoted is set for
+// "character-string" tokens so callers can tell `"@"` from a bare `@`.
+type zoneToken struct {
+	Value  string
+	Text   string // the token as written, quotes and escapes intact
+	Quoted bool
+	Paren  bool // the token is a grouping "(" or ")"
+}
+
+// zoneEntry is one entry of a master file (RFC 1035 section 5.1) before it is
+// interpreted as a directive or a resource record.
+type zoneEntry struct {
+	Line         int         // line number the entry started on
+	Tokens       []zoneToken // tokens with comments and grouping parentheses removed
+	OwnerOmitted bool        // the entry started with whitespace, so the previous owner applies
+	Comment      string      // text following ';', used as the record description
+	Group        string      // rr_set_group set by the last group= comment line
+	Raw          string      // the entry's physical lines, joined by a space
+	Depth        int         // "(" not yet closed while the entry is being read
+}
+
+// zoneLexer splits a master file into entries. Quoted strings, backslash
+// escapes (\X and \DDD) and ';' comments are handled here so the record parser
+// only ever deals with clean tokens.
+type zoneLexer struct {
+	scanner *bufio.Scanner
+	line    int
+	group   string // group= tag of the last comment-only line naming one
+}
+
+func newZoneLexer(r io.Reader) *zoneLexer {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	return &zoneLexer{scanner: scanner}
+}
+
+// Next returns the next non-empty entry, or io.EOF once the input is exhausted.
+// An entry that opens a "(" continues over as many lines as it takes for the
+// parentheses to balance, whatever the record type.
+func (l *zoneLexer) Next() (*zoneEntry, error) {
+	var entry *zoneEntry
+
+	for l.scanner.Scan() {
+		l.line++
+		raw := l.scanner.Text()
+
+		tokens, comment, err := tokenizeZoneLine(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", l.line, err)
+		}
+
+		if entry == nil {
+			if len(tokens) == 0 {
+				// Blank or comment-only line, a group= tag applies to the records below it
+				if group, ok := commentGroupTag(comment); ok {
+					l.group = group
+				}
+				continue
+			}
+			entry = &zoneEntry{
+				Line:         l.line,
+				OwnerOmitted: raw[0] == ' ' || raw[0] == '\t',
+				Comment:      comment,
+				Group:        l.group,
+				Raw:          raw,
+			}
+		} else {
+			// Continuation line inside parentheses
+			entry.Raw += " " + strings.TrimSpace(raw)
+			if comment != "" {
+				if entry.Comment != "" {
+					entry.Comment += " "
+				}
+				entry.Comment += comment
+			}
+		}
+
+		entry.addTokens(tokens)
+		if entry.Depth < 0 {
+			return nil, fmt.Errorf("line %d: unbalanced ')'", l.line)
+		}
+		if entry.Depth == 0 {
+			return entry, nil
+		}
+	}
+	if err := l.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		return nil, fmt.Errorf("line %d: unbalanced '(', record is never closed", entry.Line)
+	}
+	return nil, io.EOF
+}
+
+// addTokens appends tokens to the entry, tracking grouping parentheses.
+func (e *zoneEntry) addTokens(tokens []zoneToken) {
+	for _, token := range tokens {
+		if token.Paren {
+			if token.Value == "(" {
+				e.Depth++
+			} else {
+				e.Depth--
+			}
+			continue
+		}
+		e.Tokens = append(e.Tokens, token)
+	}
+}
+
+// tokenizeZoneLine splits a single physical line into tokens and returns any
+// trailing comment separately.
+func tokenizeZoneLine(line string) ([]zoneToken, string, error) {
+	var tokens []zoneToken
+	var current strings.Builder
+	inToken := false
+	start := 0
+
+	flush := func(end int) {
+		if inToken {
+			tokens = append(tokens, zoneToken{Value: current.String(), Text: line[start:end]})
+			current.Reset()
+			inToken = false
+		}
+	}
+	begin := func(i int) {
+		if !inToken {
+			start = i
+			inToken = true
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			flush(i)
+		case c == ';':
+			flush(i)
+			return tokens, strings.TrimSpace(line[i+1:]), nil
+		case c == '(' || c == ')':
+			flush(i)
+			tokens = append(tokens, zoneToken{Value: string(c), Text: string(c), Paren: true})
+		case c == '"':
+			flush(i)
+			value, next, err := readQuotedString(line, i+1)
+			if err != nil {
+				return nil, "", err
+			}
+			tokens = append(tokens, zoneToken{Value: value, Text: line[i : next+1], Quoted: true})
+			i = next
+		case c == '\\':
+			begin(i)
+			b, next, err := readEscape(line, i+1)
+			if err != nil {
+				return nil, "", err
+			}
+			current.WriteByte(b)
+			i = next
+		default:
+			begin(i)
+			current.WriteByte(c)
+		}
+	}
+	flush(len(line))
+
+	return tokens, "", nil
+}
+
+// readQuotedString reads a character-string starting just after the opening
+// quote and returns its value and the index of the closing quote.
+func readQuotedString(line string, start int) (string, int, error) {
+	var value strings.Builder
+	for i := start; i < len(line); i++ {
+		switch line[i] {
+		case '"':
+			return value.String(), i, nil
+		case '\\':
+			b, next, err := readEscape(line, i+1)
+			if err != nil {
+				return "", 0, err
+			}
+			value.WriteByte(b)
+			i = next
+		default:
+			value.WriteByte(line[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated quoted string")
+}
+
+// readEscape decodes the escape following a backslash at line[start-1]. It
+// returns the decoded byte and the index of the last character consumed.
+func readEscape(line string, start int) (byte, int, error) {
+	if start >= len(line) {
+		return 0, 0, fmt.Errorf("dangling escape at end of line")
+	}
+	if !isDigit(line[start]) {
+		return line[start], start, nil
+	}
+
+	// \DDD is a three digit decimal octet value
+	if start+2 >= len(line) || !isDigit(line[start+1]) || !isDigit(line[start+2]) {
+		return 0, 0, fmt.Errorf("invalid \\DDD escape %q", line[start-1:])
+	}
+	value := int(line[start]-'0')*100 + int(line[start+1]-'0')*10 + int(line[start+2]-'0')
+	if value > 255 {
+		return 0, 0, fmt.Errorf("invalid \\DDD escape \\%s", line[start:start+3])
+	}
+	return byte(value), start + 2, nil
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
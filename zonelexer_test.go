@@ -0,0 +1,133 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTokenizeZoneLine(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		line    string
+		values  []string
+		comment string
+	}{
+		{"plain", "www IN A 192.0.2.1", []string{"www", "IN", "A", "192.0.2.1"}, ""},
+		{"comment", "www A 192.0.2.1 ; web server", []string{"www", "A", "192.0.2.1"}, "web server"},
+		{"quoted", `@ TXT "v=spf1 -all" "a;b"`, []string{"@", "TXT", "v=spf1 -all", "a;b"}, ""},
+		{"escaped character", `a\.b TXT "say \"hi\""`, []string{"a.b", "TXT", `say "hi"`}, ""},
+		{"decimal escape", `@ TXT "\065\066C" x\032y`, []string{"@", "TXT", "ABC", "x y"}, ""},
+		{"escaped semicolon", `@ TXT a\;b`, []string{"@", "TXT", "a;b"}, ""},
+	} {
+		tokens, comment, err := tokenizeZoneLine(test.line)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		var values []string
+		for _, token := range tokens {
+			values = append(values, token.Value)
+		}
+		if strings.Join(values, "|") != strings.Join(test.values, "|") || comment != test.comment {
+			t.Errorf("%s: got %q comment %q, want %q comment %q", test.name, values, comment, test.values, test.comment)
+		}
+	}
+}
+
+func TestTokenizeZoneLineErrors(t *testing.T) {
+	for _, line := range []string{
+		`@ TXT "unterminated`,
+		`@ TXT \25`,
+		`@ TXT \256`,
+		`@ TXT abc\`,
+	} {
+		if _, _, err := tokenizeZoneLine(line); err == nil {
+			t.Errorf("tokenizeZoneLine(%q) succeeded, want an error", line)
+		}
+	}
+}
+
+// Quoted tokens keep their quotes in Text, so "@" can be told from @.
+func TestTokenizeZoneLineKeepsText(t *testing.T) {
+	tokens, _, err := tokenizeZoneLine(`@ TXT "@" \065`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tokens[2].Quoted || tokens[2].Text != `"@"` || tokens[0].Quoted {
+		t.Errorf("got tokens %+v, want the second @ quoted", tokens)
+	}
+	if tokens[3].Value != "A" || tokens[3].Text != `\065` {
+		t.Errorf("got token %+v, want value A written as \\065", tokens[3])
+	}
+}
+
+func TestZoneLexerEntries(t *testing.T) {
+	lexer := newZoneLexer(strings.NewReader("; header\n\nwww IN A 192.0.2.1\n\tIN A 192.0.2.2\n"))
+
+	var entries []*zoneEntry
+	for {
+		entry, err := lexer.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0].Line != 3 || entries[0].OwnerOmitted {
+		t.Errorf("first entry at line %d, owner omitted %v, want line 3 with an owner", entries[0].Line, entries[0].OwnerOmitted)
+	}
+	if entries[1].Line != 4 || !entries[1].OwnerOmitted {
+		t.Errorf("second entry at line %d, owner omitted %v, want line 4 without an owner", entries[1].Line, entries[1].OwnerOmitted)
+	}
+}
+
+// A "(" continues a record over the following lines whatever its type, and
+// the comments on those lines are kept.
+func TestZoneLexerParentheses(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		text    string
+		values  []string
+		comment string
+	}{
+		{"soa", "@ IN SOA ns1 admin (\n 1 ; serial\n 7200 3600 1209600 300 )\n",
+			[]string{"@", "IN", "SOA", "ns1", "admin", "1", "7200", "3600", "1209600", "300"}, "serial"},
+		{"txt", "@ TXT ( \"part one \"\n \"part two\" ) ; spf\n",
+			[]string{"@", "TXT", "part one ", "part two"}, "spf"},
+		{"srv", "_sip._tcp SRV (10\n20\n5060 sip)\n",
+			[]string{"_sip._tcp", "SRV", "10", "20", "5060", "sip"}, ""},
+		{"nested", "@ TXT ((a)\n b)\n", []string{"@", "TXT", "a", "b"}, ""},
+		{"quoted paren", "@ TXT \"(\" x\n", []string{"@", "TXT", "(", "x"}, ""},
+	} {
+		entry, err := newZoneLexer(strings.NewReader(test.text)).Next()
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		var values []string
+		for _, token := range entry.Tokens {
+			values = append(values, token.Value)
+		}
+		if strings.Join(values, "|") != strings.Join(test.values, "|") || entry.Comment != test.comment || entry.Line != 1 {
+			t.Errorf("%s: got %q comment %q at line %d, want %q comment %q at line 1", test.name, values, entry.Comment, entry.Line, test.values, test.comment)
+		}
+	}
+}
+
+func TestZoneLexerUnbalancedParentheses(t *testing.T) {
+	for _, text := range []string{
+		"@ TXT ( \"never closed\"\n",
+		"@ TXT a )\n",
+	} {
+		if _, err := newZoneLexer(strings.NewReader(text)).Next(); err == nil || err == io.EOF {
+			t.Errorf("%q: got %v, want an error", text, err)
+		}
+	}
+}
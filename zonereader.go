@@ -0,0 +1,458 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// zoneRecord is a resource record as written in the master file, with the
+// owner, TTL and class resolved according to RFC 1035 inheritance rules.
+type zoneRecord struct {
+	File    string
+	Line    int
+	Owner   string // fully qualified owner name without the trailing dot
+	Name    string // owner relative to the zone apex, "" for the apex itself
+	Origin  string // $ORIGIN in effect, used to qualify relative names in rdata
+	TTL     int
+	Class   string
+	Type    string
+	RData   []zoneToken
+	Comment string
+	Group   string // rr_set_group named by a group= comment tag
+	Raw     string
+}
+
+// rdataValues returns the record data as plain strings.
+func (r zoneRecord) rdataValues() []string {
+	values := make([]string, len(r.RData))
+	for i, token := range r.RData {
+		values[i] = token.Value
+	}
+	return values
+}
+
+// recordClasses maps class mnemonics to the RecordClass values in structures.go.
+var recordClasses = map[string]int{
+	"IN":  RecordClass_IN,
+	"CS":  RecordClass_CS,
+	"CH":  RecordClass_CH,
+	"HS":  RecordClass_HS,
+	"ANY": RecordClass_any,
+}
+
+// knownRecordTypes is used to tell a record type apart from an owner name when
+// a zone file was written without the leading whitespace for an omitted owner.
+var knownRecordTypes = map[string]bool{
+	"A": true, "AAAA": true, "AFSDB": true, "APL": true, "CAA": true, "CDNSKEY": true,
+	"CDS": true, "CERT": true, "CNAME": true, "DHCID": true, "DLV": true, "DNAME": true,
+	"DNSKEY": true, "DS": true, "EUI48": true, "EUI64": true, "HINFO": true, "HIP": true,
+	"HTTPS": true, "IPSECKEY": true, "KEY": true, "KX": true, "LOC": true, "MX": true,
+	"NAPTR": true, "NS": true, "NSEC": true, "NSEC3": true, "NSEC3PARAM": true,
+	"OPENPGPKEY": true, "PTR": true, "RP": true, "RRSIG": true, "SIG": true, "SMIMEA": true,
+	"SOA": true, "SPF": true, "SRV": true, "SSHFP": true, "SVCB": true, "TA": true,
+	"TKEY": true, "TLSA": true, "TSIG": true, "TXT": true, "URI": true, "ZONEMD": true,
+}
+
+// skipEntry reports an entry that could not be parsed into a record. The owner
+// and type are filled in as far as they can be told from the tokens.
+func (zr *zoneReader) skipEntry(entry *zoneEntry, filePath string, reason string) {
+	skipped := SkippedRecord{
+		File:   filePath,
+		Line:   entry.Line,
+		Reason: reason,
+		Text:   strings.TrimSpace(entry.Raw),
+	}
+	for i, token := range entry.Tokens {
+		if i == 0 && !entry.OwnerOmitted {
+			skipped.Owner = token.Value
+			if strings.HasPrefix(token.Value, "$") {
+				skipped.Owner = ""
+				skipped.Type = strings.ToUpper(token.Value)
+				break
+			}
+			continue
+		}
+		if !token.Quoted && isRecordType(token.Value) {
+			skipped.Type = strings.ToUpper(token.Value)
+			break
+		}
+	}
+
+	zr.opts.Zone.recordSkipped(skipped)
+}
+
+func isRecordClass(s string) bool {
+	s = strings.ToUpper(s)
+	if _, ok := recordClasses[s]; ok {
+		return true
+	}
+	return strings.HasPrefix(s, "CLASS") && isInt(s[5:])
+}
+
+func isRecordType(s string) bool {
+	s = strings.ToUpper(s)
+	return knownRecordTypes[s] || (strings.HasPrefix(s, "TYPE") && isInt(s[4:]))
+}
+
+// zoneReader turns master file entries into a flat stream of zoneRecords,
+// following $INCLUDE files and tracking $ORIGIN and $TTL along the way.
+type zoneReader struct {
+	rootPath     string
+	customOrigin string
+
+	apex     string // zone name the records are made relative to
+	fileApex string // first $ORIGIN seen, rebased onto customOrigin if one was given
+	origin   string // current $ORIGIN
+
+	defaultTTL   int
+	ttlDirective bool // a $TTL directive has been seen
+
+	lastOwner string
+	lastTTL   int
+	lastClass string
+
+	mainFile string // file or transfer the zone is read from, $INCLUDEs aside
+	records  []zoneRecord
+
+	opts conversionOptions // settings of the conversion, skipped entries are collected in opts.Zone
+}
+
+func newZoneReader(customOrigin, rootPath string, defaultTTL int, opts conversionOptions) *zoneReader {
+	customOrigin = strings.TrimSuffix(customOrigin, ".")
+	return &zoneReader{
+		opts:         opts,
+		rootPath:     rootPath,
+		customOrigin: customOrigin,
+		apex:         customOrigin,
+		origin:       customOrigin,
+		defaultTTL:   defaultTTL,
+		lastClass:    "IN",
+	}
+}
+
+// readFile reads every entry of filePath, appending records to zr.records.
+func (zr *zoneReader) readFile(filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	return zr.read(file, filePath)
+}
+
+func (zr *zoneReader) read(r io.Reader, filePath string) error {
+	if zr.mainFile == "" {
+		zr.mainFile = filePath
+	}
+	lexer := newZoneLexer(r)
+
+	var inZoneBlock bool // Flag to indicate we're currently processing a zone block for includes
+	var zoneConfigLines []string
+
+	for {
+		entry, err := lexer.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", filePath, err)
+		}
+
+		// Handle the start and end of a zone block
+		trimmedLine := strings.TrimSpace(entry.Raw)
+		if !inZoneBlock && !entry.OwnerOmitted && entry.Tokens[0].Value == "zone" && len(entry.Tokens) > 1 && entry.Tokens[1].Quoted {
+			inZoneBlock = true
+			zoneConfigLines = nil // Start a new zone config block
+		}
+		if inZoneBlock {
+			zoneConfigLines = append(zoneConfigLines, trimmedLine)
+			if strings.HasSuffix(trimmedLine, "};") {
+				inZoneBlock = false // End of zone config block
+				zr.processZoneBlockLines(zoneConfigLines)
+			}
+			continue
+		}
+
+		first := entry.Tokens[0]
+		if !entry.OwnerOmitted && !first.Quoted && strings.HasPrefix(first.Value, "$") {
+			if err := zr.directive(entry, filePath); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := zr.addRecord(entry, filePath); err != nil {
+			return err
+		}
+	}
+}
+
+// addRecord parses entry and appends it to the record stream. Malformed records
+// are reported and skipped, only a missing origin stops the read.
+func (zr *zoneReader) addRecord(entry *zoneEntry, filePath string) error {
+	if zr.origin == "" {
+		// No customOrigin and no $ORIGIN has been specified or detected
+		return fmt.Errorf("no $ORIGIN specified and none detected in the file")
+	}
+
+	record, err := zr.parseRecord(entry)
+	if err != nil {
+		zr.skipEntry(entry, filePath, err.Error())
+		return nil
+	}
+	record.File = filePath
+	zr.records = append(zr.records, record)
+
+	return nil
+}
+
+func (zr *zoneReader) processZoneBlockLines(zoneConfigLines []string) {
+	domainName, zoneFilePath, err := processZoneBlock(zoneConfigLines)
+	if err != nil {
+		fmt.Printf("Error processing zone block: %v\n", err)
+		return
+	}
+	// Now domainName can be used for the output filename
+	if domainName != "" && zoneFilePath != "" {
+		fmt.Printf("Processing %s from %s\n", domainName, zoneFilePath)
+
+		processIncludedZoneFile(zoneFilePath, zoneOutputFileName(domainName, "", zr.opts.Format), domainName, zr.opts)
+	}
+}
+
+// directive handles the $ORIGIN, $TTL, $INCLUDE and $GENERATE control entries.
+func (zr *zoneReader) directive(entry *zoneEntry, filePath string) error {
+	args := entry.Tokens[1:]
+
+	switch strings.ToUpper(entry.Tokens[0].Value) {
+	case "$ORIGIN":
+		if len(args) < 1 {
+			return fmt.Errorf("%s: line %d: $ORIGIN without a domain name", filePath, entry.Line)
+		}
+		zr.setOrigin(args[0].Value)
+	case "$TTL":
+		if len(args) < 1 {
+			return fmt.Errorf("%s: line %d: $TTL without a value", filePath, entry.Line)
+		}
+		ttlValue, err := parseTTL(args[0].Value)
+		if err != nil {
+			fmt.Printf("Error parsing TTL value '%s': %v. Using default TTL: %d\n", args[0].Value, err, zr.defaultTTL)
+			return nil
+		}
+		zr.defaultTTL = ttlValue
+		zr.ttlDirective = true
+	case "$INCLUDE":
+		if len(args) < 1 {
+			return fmt.Errorf("%s: line %d: $INCLUDE without a file name", filePath, entry.Line)
+		}
+		includeOrigin := zr.origin // Default to using the current origin if not specified in $INCLUDE
+		if len(args) >= 2 {
+			includeOrigin = zr.absoluteName(args[1].Value)
+		}
+		if err := zr.includeFile(args[0].Value, includeOrigin); err != nil {
+			zr.skipEntry(entry, filePath, fmt.Sprintf("error processing $INCLUDE: %v", err))
+		}
+	case "$GENERATE":
+		return zr.generate(entry, filePath)
+	default:
+		zr.skipEntry(entry, filePath, "unsupported directive")
+	}
+
+	return nil
+}
+
+// generate expands a $GENERATE directive into ordinary records.
+func (zr *zoneReader) generate(entry *zoneEntry, filePath string) error {
+	lines, err := expandGenerate(entry.Tokens[1:])
+	if errors.Is(err, errGenerateTooLarge) {
+		return fmt.Errorf("%s: line %d: %v", filePath, entry.Line, err)
+	}
+	if err != nil {
+		zr.skipEntry(entry, filePath, err.Error())
+		return nil
+	}
+
+	for _, line := range lines {
+		tokens, _, err := tokenizeZoneLine(line)
+		if err != nil {
+			zr.skipEntry(&zoneEntry{Line: entry.Line, Raw: line}, filePath, err.Error())
+			continue
+		}
+
+		generated := &zoneEntry{Line: entry.Line, Comment: entry.Comment, Group: entry.Group, Raw: line}
+		generated.addTokens(tokens)
+		if err := zr.addRecord(generated, filePath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setOrigin applies a $ORIGIN directive. When the user supplied -origin, the
+// first $ORIGIN in the file is taken to be the zone apex and is replaced, later
+// ones are rebased from the file's apex onto the custom origin.
+func (zr *zoneReader) setOrigin(value string) {
+	found := zr.absoluteName(value)
+
+	if zr.fileApex == "" {
+		zr.fileApex = found
+		if zr.customOrigin == "" {
+			zr.apex = found
+			zr.origin = found
+		}
+		return
+	}
+
+	if zr.customOrigin != "" {
+		if strings.EqualFold(found, zr.fileApex) {
+			found = zr.customOrigin
+		} else if strings.HasSuffix(strings.ToLower(found), "."+strings.ToLower(zr.fileApex)) {
+			found = found[:len(found)-len(zr.fileApex)] + zr.customOrigin
+		}
+	}
+	zr.origin = found
+}
+
+// includeFile reads an $INCLUDE file with its own origin. The origin and last
+// owner revert once the included file is done, as RFC 1035 requires.
+func (zr *zoneReader) includeFile(name, includeOrigin string) error {
+	includeFilePath := name
+	if !filepath.IsAbs(includeFilePath) {
+		includeFilePath = filepath.Join(filepath.Clean(zr.rootPath), name) // Construct the full path of the included file
+	}
+
+	savedOrigin, savedOwner := zr.origin, zr.lastOwner
+	defer func() {
+		zr.origin, zr.lastOwner = savedOrigin, savedOwner
+	}()
+
+	zr.origin = includeOrigin
+	return zr.readFile(includeFilePath)
+}
+
+// parseRecord interprets an entry as "[owner] [ttl] [class] type rdata", where
+// TTL and class may appear in either order.
+func (zr *zoneReader) parseRecord(entry *zoneEntry) (zoneRecord, error) {
+	tokens := entry.Tokens
+	record := zoneRecord{Line: entry.Line, Comment: entry.Comment, Group: entry.Group, Raw: entry.Raw}
+	// A tag on the record itself wins over the one of its section, and is
+	// not part of the record description
+	if group, ok := commentGroupTag(entry.Comment); ok {
+		record.Group = group
+		record.Comment = strings.TrimSpace(groupTagPattern.ReplaceAllString(entry.Comment, " "))
+	}
+
+	i := 0
+	owner := zr.lastOwner
+	// A name in the first column is the owner (RFC 1035 section 5.1), so
+	// "ns IN A ..." is a host called ns and "in A ..." one called in. Older
+	// tooling sometimes writes "IN A ..." there with the owner left out; that
+	// reading is only taken when the tokens after the first cannot be a record.
+	if !entry.OwnerOmitted && (startsRecord(tokens[1:]) || !startsRecord(tokens)) {
+		owner = zr.absoluteName(tokens[0].Value)
+		i++
+	}
+	if owner == "" {
+		owner = zr.origin
+	}
+
+	ttl, hasTTL, class, typeIndex := splitRecordHeader(tokens[i:])
+	i += typeIndex
+
+	if i >= len(tokens) {
+		return zoneRecord{}, fmt.Errorf("missing record type")
+	}
+	if !isRecordType(tokens[i].Value) {
+		return zoneRecord{}, fmt.Errorf("unknown record type %q", tokens[i].Value)
+	}
+	record.Type = strings.ToUpper(tokens[i].Value)
+	record.RData = tokens[i+1:]
+
+	if !hasTTL {
+		// RFC 2308: $TTL applies to records without one, before that the
+		// last explicit TTL carries forward as in RFC 1035
+		if zr.ttlDirective || zr.lastTTL <= 0 {
+			ttl = zr.defaultTTL
+		} else {
+			ttl = zr.lastTTL
+		}
+	} else {
+		zr.lastTTL = ttl
+	}
+	if class == "" {
+		class = zr.lastClass
+	}
+
+	zr.lastOwner = owner
+	zr.lastClass = class
+
+	record.Owner = owner
+	record.Name = zr.relativeName(owner)
+	record.Origin = zr.origin
+	record.TTL = ttl
+	record.Class = class
+
+	return record, nil
+}
+
+// splitRecordHeader reads the optional TTL and class, in either order, from
+// the start of tokens and returns the index of the token that should be the
+// record type.
+func splitRecordHeader(tokens []zoneToken) (ttl int, hasTTL bool, class string, typeIndex int) {
+	for typeIndex < len(tokens) && !tokens[typeIndex].Quoted {
+		if !hasTTL {
+			if value, err := parseTTL(tokens[typeIndex].Value); err == nil {
+				ttl, hasTTL = value, true
+				typeIndex++
+				continue
+			}
+		}
+		if class == "" && isRecordClass(tokens[typeIndex].Value) {
+			class = strings.ToUpper(tokens[typeIndex].Value)
+			typeIndex++
+			continue
+		}
+		break
+	}
+	return ttl, hasTTL, class, typeIndex
+}
+
+// startsRecord reports whether tokens read as "[ttl] [class] type".
+func startsRecord(tokens []zoneToken) bool {
+	_, _, _, typeIndex := splitRecordHeader(tokens)
+	return typeIndex < len(tokens) && !tokens[typeIndex].Quoted && isRecordType(tokens[typeIndex].Value)
+}
+
+// absoluteName qualifies a domain name against the current origin and returns
+// it without the trailing dot.
+func (zr *zoneReader) absoluteName(name string) string {
+	if name == "@" {
+		return zr.origin
+	}
+	if strings.HasSuffix(name, ".") {
+		return strings.TrimSuffix(name, ".")
+	}
+	if zr.origin == "" {
+		return name
+	}
+	return name + "." + zr.origin
+}
+
+// relativeName strips the zone apex from a fully qualified name. The apex
+// itself becomes "" and names outside the zone are returned unchanged.
+func (zr *zoneReader) relativeName(name string) string {
+	lowerName, lowerApex := strings.ToLower(name), strings.ToLower(zr.apex)
+	if lowerName == lowerApex {
+		return ""
+	}
+	if strings.HasSuffix(lowerName, "."+lowerApex) {
+		return name[:len(name)-len(zr.apex)-1]
+	}
+	return name
+}
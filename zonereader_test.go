@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// recordSummary formats the owner, TTL, class and type of a record.
+func recordSummary(record zoneRecord) string {
+	return fmt.Sprintf("%s %d %s %s", record.Owner, record.TTL, record.Class, record.Type)
+}
+
+func TestParseRecordHeaders(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		record string
+		want   string
+	}{
+		{"ttl then class", "www 300 IN A 192.0.2.1", "www.example.com 300 IN A"},
+		{"class then ttl", "www IN 300 A 192.0.2.1", "www.example.com 300 IN A"},
+		{"no ttl or class", "www A 192.0.2.1", "www.example.com 3600 IN A"},
+		{"apex", "@ CH TXT x", "example.com 3600 CH TXT"},
+		{"absolute owner", "www.example.net. A 192.0.2.1", "www.example.net 3600 IN A"},
+		{"owner named like a class", "in A 192.0.2.1", "in.example.com 3600 IN A"},
+		{"owner named like a type", "ns IN A 192.0.2.1", "ns.example.com 3600 IN A"},
+	} {
+		records := readTestZone(t, "$ORIGIN example.com.\n$TTL 3600\n"+test.record+"\n")
+		if len(records) != 1 {
+			t.Errorf("%s: got %d records, want 1", test.name, len(records))
+			continue
+		}
+		if got := recordSummary(records[0]); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+// A line starting with whitespace, or with a type in the first column that
+// cannot be an owner, belongs to the previous owner.
+func TestParseRecordOmittedOwner(t *testing.T) {
+	records := readTestZone(t, `$ORIGIN example.com.
+$TTL 3600
+www IN A 192.0.2.1
+    IN A 192.0.2.2
+	AAAA 2001:db8::1
+TXT "legacy"
+in A 192.0.2.3
+mail IN MX 10 mx
+`)
+
+	want := []string{
+		"www.example.com 3600 IN A",
+		"www.example.com 3600 IN A",
+		"www.example.com 3600 IN AAAA",
+		"www.example.com 3600 IN TXT",
+		"in.example.com 3600 IN A",
+		"mail.example.com 3600 IN MX",
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(records), len(want))
+	}
+	for i, record := range records {
+		if got := recordSummary(record); got != want[i] {
+			t.Errorf("record %d: got %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+// Before a $TTL the last explicit TTL carries forward (RFC 1035); once one is
+// seen it applies to every record without a TTL (RFC 2308).
+func TestParseRecordTTLScope(t *testing.T) {
+	records := readTestZone(t, `$ORIGIN example.com.
+a A 192.0.2.1
+b 600 A 192.0.2.2
+c A 192.0.2.3
+$TTL 1200
+d A 192.0.2.4
+e 60 A 192.0.2.5
+f A 192.0.2.6
+`)
+
+	want := map[string]int{"a": defaultTTLValue, "b": 600, "c": 600, "d": 1200, "e": 60, "f": 1200}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(records), len(want))
+	}
+	for _, record := range records {
+		if record.TTL != want[record.Name] {
+			t.Errorf("%s: got TTL %d, want %d", record.Name, record.TTL, want[record.Name])
+		}
+	}
+}
+
+// An $INCLUDE reads its file with its own origin, and the origin and the
+// last owner revert once it is done.
+func TestIncludeRestoresOrigin(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "hosts.inc"), []byte("$ORIGIN other.example.com.\nhost A 192.0.2.9\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub.inc"), []byte("www A 192.0.2.8\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	zone := `$ORIGIN example.com.
+$TTL 3600
+www A 192.0.2.1
+$INCLUDE hosts.inc
+    A 192.0.2.2
+after A 192.0.2.3
+$INCLUDE sub.inc sub
+last A 192.0.2.4
+`
+	path := filepath.Join(dir, "test.zone")
+	if err := os.WriteFile(path, []byte(zone), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := newZoneReader("", dir, defaultTTLValue, newConversionOptions())
+	if err := reader.readFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"www.example.com",
+		"host.other.example.com",
+		"www.example.com",
+		"after.example.com",
+		"www.sub.example.com",
+		"last.example.com",
+	}
+	if len(reader.records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(reader.records), len(want))
+	}
+	for i, record := range reader.records {
+		if record.Owner != want[i] {
+			t.Errorf("record %d: got owner %s, want %s", i, record.Owner, want[i])
+		}
+	}
+	if file := filepath.Base(reader.records[1].File); file != "hosts.inc" {
+		t.Errorf("included record read from %s, want hosts.inc", file)
+	}
+}